@@ -26,6 +26,9 @@ type StoreConfig struct {
 		Name string
 		Path string
 	}
+
+	Types printer.TypeOptions `toml:"types"`
+	JSON  printer.JSONTypes   `toml:"json"`
 }
 
 func main() {
@@ -84,7 +87,10 @@ func run(ctx context.Context) error {
 		}
 
 		engine := pgengine.New(conn)
-		printer := pgprinter.New(store.Package.Name, config.Overrides)
+		printer := pgprinter.New(store.Package.Name, config.Overrides,
+			pgprinter.WithTypeOptions(store.Types),
+			pgprinter.WithJSONTypes(store.JSON),
+		)
 
 		if err := otter.New(engine, printer).Run(ctx,
 			store.Queries,
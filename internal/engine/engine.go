@@ -2,29 +2,55 @@ package engine
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
 type TypeKind string
 
 var (
-	TypeKindBase TypeKind = "base"
-	TypeKindEnum TypeKind = "enum"
+	TypeKindBase       TypeKind = "base"
+	TypeKindEnum       TypeKind = "enum"
+	TypeKindComposite  TypeKind = "composite"
+	TypeKindDomain     TypeKind = "domain"
+	TypeKindArray      TypeKind = "array"
+	TypeKindRange      TypeKind = "range"
+	TypeKindMultirange TypeKind = "multirange"
 )
 
 type Type struct {
-	Kind     TypeKind
-	Name     string
-	Nullable bool
-	Variants []string
+	Kind       TypeKind
+	Name       string
+	Nullable   bool
+	Variants   []string
+	Attributes []Attribute
+	Base       *Type
+
+	// Elem is the element type of an array, or the subtype of a range
+	// or multirange.
+	Elem *Type
+
+	// Precision and Scale are those declared for a numeric, such as
+	// numeric(12,2). Precision is zero when none was declared.
+	Precision int
+	Scale     int
+}
+
+type Attribute struct {
+	Name string
+	Type Type
 }
 
 type QueryType string
 
 var (
-	QueryTypeExec QueryType = "exec"
-	QueryTypeOne  QueryType = "one"
-	QueryTypeMany QueryType = "many"
+	QueryTypeExec       QueryType = "exec"
+	QueryTypeExecRows   QueryType = "execrows"
+	QueryTypeExecResult QueryType = "execresult"
+	QueryTypeOne        QueryType = "one"
+	QueryTypeMany       QueryType = "many"
 )
 
 type Input struct {
@@ -35,6 +61,10 @@ type Input struct {
 type Output struct {
 	Name string
 	Type Type
+
+	// Column is the table column the output is read from, as
+	// `table.column`, or empty when it is not read from one.
+	Column string
 }
 
 type Query struct {
@@ -49,46 +79,160 @@ type Result struct {
 	Types []Type
 
 	Queries map[string]Query
+
+	// Warnings are problems found with the queries that do not stop
+	// code from being generated for them.
+	Warnings []string
+}
+
+// QuerySource is the SQL of a query along with where it was read from, so
+// that problems with its header are reported against its file.
+type QuerySource struct {
+	SQL  string
+	File string
+
+	// Line is the line of the file that the query starts on.
+	Line int
 }
 
 type Engine interface {
-	ResolveQueries(ctx context.Context, queries map[string]string) (Result, error)
+	ResolveQueries(ctx context.Context, queries map[string]QuerySource) (Result, error)
 }
 
-func ParseQueryInputNames(query string) map[string]string {
+var (
+	namedInputPattern      = regexp.MustCompile(`^(?:@([A-Za-z_][A-Za-z0-9_]*)|sqlc\.arg\(\s*'?([A-Za-z_][A-Za-z0-9_]*)'?\s*\))`)
+	positionalInputPattern = regexp.MustCompile(`^\$([0-9]+)`)
+)
+
+// RewriteNamedInputs rewrites the named parameters of a query, such as
+// `@user_id` or `sqlc.arg(user_id)`, into positional parameters numbered
+// after any positional parameters already in the query. Repeated names
+// share the same position. Anything within a comment, string or quoted
+// identifier is left alone. It returns the rewritten query along with the
+// name given to each position.
+func RewriteNamedInputs(query string) (string, map[string]string) {
 	inputNames := make(map[string]string)
+	positions := make(map[string]int)
+
+	nextPosition := 1
+	for _, position := range PositionalInputs(query) {
+		nextPosition = max(nextPosition, position+1)
+	}
 
-	for queryLine := range strings.SplitSeq(query, "\n") {
-		queryLine = strings.TrimSpace(queryLine)
-		queryLine, hasArgument := strings.CutPrefix(queryLine, "-- $")
-		if !hasArgument {
+	var rewritten strings.Builder
+
+	for idx := 0; idx < len(query); idx++ {
+		if end := SkipNonCode(query, idx); end > idx {
+			rewritten.WriteString(query[idx:end])
+			idx = end - 1
 			continue
 		}
 
-		arg, name, hasName := strings.Cut(queryLine, ":")
-		if !hasName {
-			continue
+		c := query[idx]
+
+		// Named parameters cannot follow on from an identifier, and are
+		// not to be confused with operators such as `<@` or `@@`.
+		if (c == '@' || c == 's') && (idx == 0 || !isNamedInputBoundary(query[idx-1])) {
+			if match := namedInputPattern.FindStringSubmatch(query[idx:]); match != nil {
+				name := match[1] + match[2]
+
+				position, found := positions[name]
+				if !found {
+					position = nextPosition
+					positions[name] = position
+					inputNames[strconv.Itoa(position)] = name
+					nextPosition++
+				}
+
+				fmt.Fprintf(&rewritten, "$%d", position)
+				idx += len(match[0]) - 1
+				continue
+			}
 		}
 
-		inputNames[strings.TrimSpace(arg)] = strings.TrimSpace(name)
+		rewritten.WriteByte(c)
 	}
 
-	return inputNames
+	return rewritten.String(), inputNames
 }
 
-func ParseQueryType(query string) QueryType {
-	for queryLine := range strings.SplitSeq(query, "\n") {
-		queryLine = strings.TrimSpace(queryLine)
-
-		switch queryLine {
-		case "-- :one":
-			return QueryTypeOne
-		case "-- :exec":
-			return QueryTypeExec
-		case "-- :many":
-			return QueryTypeMany
+// PositionalInputs returns the position of every positional parameter in
+// the query, such as `$1`, in the order they appear. Anything within a
+// comment, string or quoted identifier is left out.
+func PositionalInputs(query string) []int {
+	var positions []int
+	scanPositionalInputs(query, func(_, _, position int) {
+		positions = append(positions, position)
+	})
+
+	return positions
+}
+
+// RewritePositionalInputs replaces every positional parameter in the query
+// with the text returned for its position. Anything within a comment,
+// string or quoted identifier is left alone.
+func RewritePositionalInputs(query string, rewrite func(position int) string) string {
+	var rewritten strings.Builder
+
+	last := 0
+	scanPositionalInputs(query, func(start, end, position int) {
+		rewritten.WriteString(query[last:start])
+		rewritten.WriteString(rewrite(position))
+		last = end
+	})
+
+	rewritten.WriteString(query[last:])
+	return rewritten.String()
+}
+
+// scanPositionalInputs calls found with the start, end and position of
+// every positional parameter in the query.
+func scanPositionalInputs(query string, found func(start, end, position int)) {
+	for idx := 0; idx < len(query); idx++ {
+		if end := SkipNonCode(query, idx); end > idx {
+			idx = end - 1
+			continue
+		}
+
+		if query[idx] != '$' || idx > 0 && isIdentifierByte(query[idx-1]) {
+			continue
+		}
+
+		if match := positionalInputPattern.FindStringSubmatch(query[idx:]); match != nil {
+			if position, err := strconv.Atoi(match[1]); err == nil {
+				found(idx, idx+len(match[0]), position)
+			}
+
+			idx += len(match[0]) - 1
 		}
 	}
+}
+
+func isNamedInputBoundary(c byte) bool {
+	return c == '_' || c == '.' || c == '$' || c == '<' || c == '@' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
 
-	return QueryTypeMany
+// ParseOutputName parses the name of an output column, which may end in
+// `!` to make the output non-null or `?` to make it nullable, such as
+// `select name as "name!"`. The elements of an array are made non-null or
+// nullable by a following `[!]` or `[?]`, such as `"tags![!]"`. The
+// suffixes are removed from the returned name.
+func ParseOutputName(name string) (string, *bool, *bool) {
+	name, elemNullable := cutNullabilitySuffix(name, "[?]", "[!]")
+	name, nullable := cutNullabilitySuffix(name, "?", "!")
+
+	return name, nullable, elemNullable
+}
+
+func cutNullabilitySuffix(name, nullableSuffix, notNullSuffix string) (string, *bool) {
+	switch {
+	case strings.HasSuffix(name, nullableSuffix):
+		nullable := true
+		return strings.TrimSuffix(name, nullableSuffix), &nullable
+	case strings.HasSuffix(name, notNullSuffix):
+		nullable := false
+		return strings.TrimSuffix(name, notNullSuffix), &nullable
+	default:
+		return name, nil
+	}
 }
@@ -0,0 +1,133 @@
+package engine_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DanielleMaywood/otter/internal/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewriteNamedInputs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedQuery  string
+		expectedInputs map[string]string
+	}{
+		{
+			name:           "NamedInputs",
+			query:          "select * from users where id = @id and name = sqlc.arg(name) or id = @id",
+			expectedQuery:  "select * from users where id = $1 and name = $2 or id = $1",
+			expectedInputs: map[string]string{"1": "id", "2": "name"},
+		},
+		{
+			name:           "QuotedSqlcArg",
+			query:          "select sqlc.arg('name')",
+			expectedQuery:  "select $1",
+			expectedInputs: map[string]string{"1": "name"},
+		},
+		{
+			name:           "AfterPositionalInputs",
+			query:          "select $2, @id, $1",
+			expectedQuery:  "select $2, $3, $1",
+			expectedInputs: map[string]string{"3": "id"},
+		},
+		{
+			name:           "PositionalInputAfterComment",
+			query:          "select '--', $3, @id",
+			expectedQuery:  "select '--', $3, $4",
+			expectedInputs: map[string]string{"4": "id"},
+		},
+		{
+			name:           "Operators",
+			query:          "select tags <@ $1, @ -1, body @@ query from posts",
+			expectedQuery:  "select tags <@ $1, @ -1, body @@ query from posts",
+			expectedInputs: map[string]string{},
+		},
+		{
+			name:           "LineComment",
+			query:          "-- $5: @x\nselect @id",
+			expectedQuery:  "-- $5: @x\nselect $1",
+			expectedInputs: map[string]string{"1": "id"},
+		},
+		{
+			name:           "NestedBlockComment",
+			query:          "select /* @x /* $7 */ @y */ @id",
+			expectedQuery:  "select /* @x /* $7 */ @y */ $1",
+			expectedInputs: map[string]string{"1": "id"},
+		},
+		{
+			name:           "Strings",
+			query:          `select '@x $9', 'it''s @x', E'it\'s @x', @id`,
+			expectedQuery:  `select '@x $9', 'it''s @x', E'it\'s @x', $1`,
+			expectedInputs: map[string]string{"1": "id"},
+		},
+		{
+			name:           "QuotedIdentifiers",
+			query:          `select "@x" as "a "" @y", @id`,
+			expectedQuery:  `select "@x" as "a "" @y", $1`,
+			expectedInputs: map[string]string{"1": "id"},
+		},
+		{
+			name:           "DollarQuotes",
+			query:          "select $$ @x $1 $$, $tag$ @y $$ $tag$, a$b, @id",
+			expectedQuery:  "select $$ @x $1 $$, $tag$ @y $$ $tag$, a$b, $1",
+			expectedInputs: map[string]string{"1": "id"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			query, inputs := engine.RewriteNamedInputs(tt.query)
+			assert.Equal(t, tt.expectedQuery, query)
+			assert.Equal(t, tt.expectedInputs, inputs)
+		})
+	}
+}
+
+func TestPositionalInputs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []int
+	}{
+		{
+			name:     "PositionalInputs",
+			query:    "select $1, $12, $1",
+			expected: []int{1, 12, 1},
+		},
+		{
+			name:  "NonCode",
+			query: "select '$1', \"$2\", $$ $3 $$, a$4 -- $5\n/* $6 */",
+		},
+		{
+			name:     "Casts",
+			query:    "select $1::int, ($2)::text",
+			expected: []int{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, engine.PositionalInputs(tt.query))
+		})
+	}
+}
+
+func TestRewritePositionalInputs(t *testing.T) {
+	t.Parallel()
+
+	query := engine.RewritePositionalInputs("-- $1: id\nselect $1, '$1', $2 = $1", func(position int) string {
+		return fmt.Sprintf("($%d::int8)", position)
+	})
+	assert.Equal(t, "-- $1: id\nselect ($1::int8), '$1', ($2::int8) = ($1::int8)", query)
+}
@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Header holds the directives found in the comments leading a query, such
+// as `-- :one` or `-- $1: id`.
+type Header struct {
+	// Name is the name given to the query by `-- name: GetUser :one`,
+	// used when a file holds several queries.
+	Name string
+
+	// Type is the query type set by a directive such as `-- :one`. It is
+	// empty when the header does not set one.
+	Type QueryType
+
+	// Inputs holds the parameter annotations, keyed by the parameter's
+	// position.
+	Inputs map[string]InputAnnotation
+}
+
+// InputAnnotation is a parameter as annotated in a query's header, such as
+// `-- $1?: name :: int8` or `-- $1[!]: ids`.
+type InputAnnotation struct {
+	Name string
+
+	// Nullable overrides the inferred nullability of the parameter when
+	// set. It is set by `$1?` to make a parameter nullable, and by `$1!`
+	// to make it non-null.
+	Nullable *bool
+
+	// ElemNullable overrides the nullability of the elements of an array
+	// parameter, which are otherwise assumed to be nullable. It is set by
+	// `$1[?]` and `$1[!]`.
+	ElemNullable *bool
+
+	// Type overrides the inferred type of the parameter when set.
+	Type string
+}
+
+var (
+	inputAnnotationPattern = regexp.MustCompile(`^\$([1-9][0-9]*)([?!]?)(?:\[([?!])\])?\s*:\s*([A-Za-z_][A-Za-z0-9_]*)\s*(?:::\s*(\S.*))?$`)
+	nameDirectivePattern   = regexp.MustCompile(`^name:\s*([A-Za-z_][A-Za-z0-9_]*)(?:\s+(:\S+))?$`)
+)
+
+// ParseHeader parses the directives in the comments leading a query that
+// starts on the given line of its file. Any other comments are left alone,
+// but a directive that is not understood or repeats an earlier one is
+// reported along with the file and line it is on.
+func ParseHeader(file string, firstLine int, query string) (Header, error) {
+	header := Header{
+		Inputs: make(map[string]InputAnnotation),
+	}
+
+	var errs []error
+	reportf := func(line int, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s:%d: %s", file, line, fmt.Sprintf(format, args...)))
+	}
+
+	nameLine, typeLine := 0, 0
+
+	setType := func(line int, directive string) {
+		if typeLine != 0 {
+			reportf(line, "duplicate query type '%s', already set on line %d", directive, typeLine)
+			return
+		}
+
+		switch QueryType(strings.TrimPrefix(directive, ":")) {
+		case QueryTypeOne, QueryTypeMany, QueryTypeExec, QueryTypeExecRows, QueryTypeExecResult:
+			header.Type = QueryType(strings.TrimPrefix(directive, ":"))
+			typeLine = line
+		default:
+			reportf(line, "unknown query type '%s'", directive)
+		}
+	}
+
+	for idx, queryLine := range strings.Split(query, "\n") {
+		line := firstLine + idx
+
+		queryLine = strings.TrimSpace(queryLine)
+		if queryLine == "" {
+			continue
+		}
+
+		// The header ends at the first line that is not a comment.
+		comment, isComment := strings.CutPrefix(queryLine, "--")
+		if !isComment {
+			break
+		}
+
+		directive := strings.TrimSpace(comment)
+
+		switch {
+		case strings.HasPrefix(directive, ":"):
+			setType(line, directive)
+
+		case strings.HasPrefix(directive, "name:"):
+			match := nameDirectivePattern.FindStringSubmatch(directive)
+			if match == nil {
+				reportf(line, "malformed name directive '%s', expected 'name: QueryName :type'", directive)
+				continue
+			} else if nameLine != 0 {
+				reportf(line, "duplicate query name '%s', already set on line %d", match[1], nameLine)
+				continue
+			}
+
+			header.Name, nameLine = match[1], line
+			if match[2] != "" {
+				setType(line, match[2])
+			}
+
+		case strings.HasPrefix(directive, "$"):
+			match := inputAnnotationPattern.FindStringSubmatch(directive)
+			if match == nil {
+				reportf(line, "malformed parameter annotation '%s', expected '$n: name'", directive)
+				continue
+			}
+
+			arg := match[1]
+			if _, found := header.Inputs[arg]; found {
+				reportf(line, "duplicate annotation for parameter '$%s'", arg)
+				continue
+			}
+
+			input := InputAnnotation{Name: match[4], Type: strings.TrimSpace(match[5])}
+			input.Nullable = parseNullabilityMarker(match[2])
+			input.ElemNullable = parseNullabilityMarker(match[3])
+
+			header.Inputs[arg] = input
+		}
+	}
+
+	return header, errors.Join(errs...)
+}
+
+// parseNullabilityMarker parses the `?` or `!` of an annotation, returning
+// nil when there is neither.
+func parseNullabilityMarker(marker string) *bool {
+	switch marker {
+	case "?":
+		nullable := true
+		return &nullable
+	case "!":
+		nullable := false
+		return &nullable
+	default:
+		return nil
+	}
+}
+
+// FileQuery is one of the queries held in a query file.
+type FileQuery struct {
+	// Name is the name given to the query by its `-- name:` directive. It
+	// is empty when the file holds a single query without one.
+	Name string
+	SQL  string
+
+	// Line is the line of the file that the query starts on.
+	Line int
+}
+
+// SplitQueryFile splits the contents of a query file into its queries. A
+// file holds either a single query, or several queries that each start
+// with a `-- name: GetUser :one` directive. The header of every query is
+// checked, with any problems reported against the file.
+func SplitQueryFile(file, contents string) ([]FileQuery, error) {
+	lines := strings.Split(contents, "\n")
+
+	var starts []int
+	for idx, line := range lines {
+		if isNameDirective(line) {
+			starts = append(starts, idx)
+		}
+	}
+
+	if len(starts) == 0 {
+		if _, err := ParseHeader(file, 1, contents); err != nil {
+			return nil, err
+		}
+
+		return []FileQuery{{SQL: contents, Line: 1}}, nil
+	}
+
+	var errs []error
+
+	// Only comments may come before the first query, such as a comment
+	// describing the file as a whole.
+	for idx, line := range lines[:starts[0]] {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			errs = append(errs, fmt.Errorf("%s:%d: query without a name directive", file, idx+1))
+			break
+		}
+	}
+
+	queries := make([]FileQuery, 0, len(starts))
+	seen := make(map[string]int)
+
+	for idx, start := range starts {
+		end := len(lines)
+		if idx+1 < len(starts) {
+			end = starts[idx+1]
+		}
+
+		query := strings.Join(lines[start:end], "\n")
+
+		header, err := ParseHeader(file, start+1, query)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if line, found := seen[header.Name]; found {
+			errs = append(errs, fmt.Errorf("%s:%d: duplicate query '%s', already defined on line %d", file, start+1, header.Name, line))
+			continue
+		}
+
+		seen[header.Name] = start + 1
+		queries = append(queries, FileQuery{Name: header.Name, SQL: query, Line: start + 1})
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return queries, nil
+}
+
+// isNameDirective reports whether a line is a `-- name:` directive.
+func isNameDirective(line string) bool {
+	comment, isComment := strings.CutPrefix(strings.TrimSpace(line), "--")
+	return isComment && strings.HasPrefix(strings.TrimSpace(comment), "name:")
+}
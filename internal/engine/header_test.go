@@ -0,0 +1,204 @@
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/DanielleMaywood/otter/internal/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHeader(t *testing.T) {
+	t.Parallel()
+
+	nullable, notNull := true, false
+
+	tests := []struct {
+		name     string
+		query    string
+		expected engine.Header
+		errors   []string
+	}{
+		{
+			name:  "NoHeader",
+			query: "select 1",
+			expected: engine.Header{
+				Inputs: map[string]engine.InputAnnotation{},
+			},
+		},
+		{
+			name:  "QueryTypeAndInputs",
+			query: "-- :one\n-- $1: id\n-- $2?: name\n-- $3!: email :: text\nselect 1",
+			expected: engine.Header{
+				Type: engine.QueryTypeOne,
+				Inputs: map[string]engine.InputAnnotation{
+					"1": {Name: "id"},
+					"2": {Name: "name", Nullable: &nullable},
+					"3": {Name: "email", Nullable: &notNull, Type: "text"},
+				},
+			},
+		},
+		{
+			name:  "ElemNullability",
+			query: "-- $1[!]: ids\n-- $2?[?]: tags :: text[]\nselect 1",
+			expected: engine.Header{
+				Inputs: map[string]engine.InputAnnotation{
+					"1": {Name: "ids", ElemNullable: &notNull},
+					"2": {Name: "tags", Nullable: &nullable, ElemNullable: &nullable, Type: "text[]"},
+				},
+			},
+		},
+		{
+			name:  "ExecQueryTypes",
+			query: "-- name: UpdateUsers :execrows\nupdate users set name = ''",
+			expected: engine.Header{
+				Name:   "UpdateUsers",
+				Type:   engine.QueryTypeExecRows,
+				Inputs: map[string]engine.InputAnnotation{},
+			},
+		},
+		{
+			name:  "OtherComments",
+			query: "-- Gets a user by their id.\n--\n-- :one\nselect 1\n-- :onee",
+			expected: engine.Header{
+				Type:   engine.QueryTypeOne,
+				Inputs: map[string]engine.InputAnnotation{},
+			},
+		},
+		{
+			name:  "NameDirective",
+			query: "-- name: GetUser :one\n-- $1: id\nselect 1",
+			expected: engine.Header{
+				Name: "GetUser",
+				Type: engine.QueryTypeOne,
+				Inputs: map[string]engine.InputAnnotation{
+					"1": {Name: "id"},
+				},
+			},
+		},
+		{
+			name:  "DuplicateNameType",
+			query: "-- name: GetUser :one\n-- :many\nselect 1",
+			errors: []string{
+				"GetUser.sql:2: duplicate query type ':many', already set on line 1",
+			},
+		},
+		{
+			name:  "UnknownQueryType",
+			query: "-- :onee\nselect 1",
+			errors: []string{
+				"GetUser.sql:1: unknown query type ':onee'",
+			},
+		},
+		{
+			name:  "DuplicateQueryType",
+			query: "-- :one\n-- :many\nselect 1",
+			errors: []string{
+				"GetUser.sql:2: duplicate query type ':many', already set on line 1",
+			},
+		},
+		{
+			name:  "MalformedInput",
+			query: "\n-- $1 id\n-- $x: name\nselect 1",
+			errors: []string{
+				"GetUser.sql:2: malformed parameter annotation '$1 id', expected '$n: name'",
+				"GetUser.sql:3: malformed parameter annotation '$x: name', expected '$n: name'",
+			},
+		},
+		{
+			name:  "MalformedElemNullability",
+			query: "-- $1[]: ids\nselect 1",
+			errors: []string{
+				"GetUser.sql:1: malformed parameter annotation '$1[]: ids', expected '$n: name'",
+			},
+		},
+		{
+			name:  "DuplicateInput",
+			query: "-- $1: id\n-- $1: name\nselect 1",
+			errors: []string{
+				"GetUser.sql:2: duplicate annotation for parameter '$1'",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			header, err := engine.ParseHeader("GetUser.sql", 1, tt.query)
+			if len(tt.errors) == 0 {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, header)
+				return
+			}
+
+			for _, expected := range tt.errors {
+				assert.ErrorContains(t, err, expected)
+			}
+		})
+	}
+}
+
+func TestSplitQueryFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		contents string
+		expected []engine.FileQuery
+		errors   []string
+	}{
+		{
+			name:     "SingleQuery",
+			contents: "-- :one\nselect 1",
+			expected: []engine.FileQuery{
+				{SQL: "-- :one\nselect 1", Line: 1},
+			},
+		},
+		{
+			name:     "NamedQueries",
+			contents: "-- Queries for users.\n\n-- name: GetUser :one\nselect 1\n\n-- name: ListUsers :many\nselect 2\n",
+			expected: []engine.FileQuery{
+				{Name: "GetUser", SQL: "-- name: GetUser :one\nselect 1\n", Line: 3},
+				{Name: "ListUsers", SQL: "-- name: ListUsers :many\nselect 2\n", Line: 6},
+			},
+		},
+		{
+			name:     "QueryWithoutName",
+			contents: "select 1;\n-- name: GetUser :one\nselect 1",
+			errors: []string{
+				"users.sql:1: query without a name directive",
+			},
+		},
+		{
+			name:     "DuplicateQuery",
+			contents: "-- name: GetUser :one\nselect 1\n-- name: GetUser :one\nselect 2",
+			errors: []string{
+				"users.sql:3: duplicate query 'GetUser', already defined on line 1",
+			},
+		},
+		{
+			name:     "InvalidHeader",
+			contents: "-- name: GetUser :one\nselect 1\n-- name: ListUsers :manyy\nselect 2",
+			errors: []string{
+				"users.sql:3: unknown query type ':manyy'",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			queries, err := engine.SplitQueryFile("users.sql", tt.contents)
+			if len(tt.errors) == 0 {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, queries)
+				return
+			}
+
+			for _, expected := range tt.errors {
+				assert.ErrorContains(t, err, expected)
+			}
+		})
+	}
+}
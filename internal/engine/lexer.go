@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"regexp"
+	"strings"
+)
+
+var dollarQuotePattern = regexp.MustCompile(`^\$(?:[A-Za-z_\x80-\xff][A-Za-z0-9_\x80-\xff]*)?\$`)
+
+// SkipNonCode returns the index just past the comment, string constant,
+// quoted identifier or dollar-quoted string that starts at the given index
+// of the query, or the index itself when none of them start there. Anything
+// left unterminated runs to the end of the query.
+func SkipNonCode(query string, idx int) int {
+	rest := query[idx:]
+
+	switch {
+	case strings.HasPrefix(rest, "--"):
+		// The newline ending the comment is left as part of the code.
+		if end := strings.IndexByte(rest, '\n'); end >= 0 {
+			return idx + end
+		}
+
+		return len(query)
+
+	case strings.HasPrefix(rest, "/*"):
+		// Block comments nest, unlike in most other languages.
+		depth := 0
+		for end := idx; end < len(query); {
+			switch {
+			case strings.HasPrefix(query[end:], "/*"):
+				depth++
+				end += 2
+			case strings.HasPrefix(query[end:], "*/"):
+				depth--
+				end += 2
+				if depth == 0 {
+					return end
+				}
+			default:
+				end++
+			}
+		}
+
+		return len(query)
+
+	case rest[0] == '\'':
+		// Backslashes only escape characters in an E'' string.
+		escapes := idx > 0 && (query[idx-1] == 'E' || query[idx-1] == 'e') && (idx < 2 || !isIdentifierByte(query[idx-2]))
+		return skipQuoted(query, idx, '\'', escapes)
+
+	case rest[0] == '"':
+		return skipQuoted(query, idx, '"', false)
+
+	case rest[0] == '$':
+		// A `$` within an identifier does not start a dollar quote, and
+		// neither does a positional parameter such as `$1`.
+		if idx > 0 && isIdentifierByte(query[idx-1]) {
+			return idx
+		}
+
+		tag := dollarQuotePattern.FindString(rest)
+		if tag == "" {
+			return idx
+		}
+
+		if end := strings.Index(rest[len(tag):], tag); end >= 0 {
+			return idx + len(tag) + end + len(tag)
+		}
+
+		return len(query)
+
+	default:
+		return idx
+	}
+}
+
+// skipQuoted returns the index just past the quoted text starting at the
+// given index, where a doubled quote stands for the quote itself.
+func skipQuoted(query string, idx int, quote byte, escapes bool) int {
+	for end := idx + 1; end < len(query); end++ {
+		switch {
+		case escapes && query[end] == '\\':
+			end++
+		case query[end] == quote && end+1 < len(query) && query[end+1] == quote:
+			end++
+		case query[end] == quote:
+			return end + 1
+		}
+	}
+
+	return len(query)
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// StripComments removes the line and block comments from a query, leaving
+// anything that looks like a comment within a string alone.
+func StripComments(query string) string {
+	var stripped strings.Builder
+
+	for idx := 0; idx < len(query); {
+		end := SkipNonCode(query, idx)
+
+		switch {
+		case end == idx:
+			stripped.WriteByte(query[idx])
+			end++
+		case strings.HasPrefix(query[idx:], "--"):
+		case strings.HasPrefix(query[idx:], "/*"):
+			// A block comment separates the tokens either side of it.
+			stripped.WriteByte(' ')
+		default:
+			stripped.WriteString(query[idx:end])
+		}
+
+		idx = end
+	}
+
+	return stripped.String()
+}
@@ -0,0 +1,125 @@
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/DanielleMaywood/otter/internal/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripComments(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "LineComments",
+			query:    "-- :one\nselect 1 -- one\n",
+			expected: "\nselect 1 \n",
+		},
+		{
+			name:     "BlockComments",
+			query:    "select/* a /* nested */ comment */1",
+			expected: "select 1",
+		},
+		{
+			name:     "Strings",
+			query:    `select '--', E'\' -- ', "a -- b", $$ /* $$`,
+			expected: `select '--', E'\' -- ', "a -- b", $$ /* $$`,
+		},
+		{
+			name:     "UnterminatedComment",
+			query:    "select 1 /* never closed",
+			expected: "select 1  ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, engine.StripComments(tt.query))
+		})
+	}
+}
+
+func TestSkipNonCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		query    string
+		idx      int
+		expected int
+	}{
+		{
+			name:     "Code",
+			query:    "select 1",
+			expected: 0,
+		},
+		{
+			name:     "String",
+			query:    "'it''s' x",
+			expected: 7,
+		},
+		{
+			name:     "BackslashInStandardString",
+			query:    `'a\' x`,
+			expected: 4,
+		},
+		{
+			name:     "EscapeString",
+			query:    `E'a\'b' x`,
+			idx:      1,
+			expected: 7,
+		},
+		{
+			name:     "IdentifierEndingInE",
+			query:    `the'a\' x`,
+			idx:      3,
+			expected: 7,
+		},
+		{
+			name:     "QuotedIdentifier",
+			query:    `"a""b" x`,
+			expected: 6,
+		},
+		{
+			name:     "DollarQuote",
+			query:    "$a$ $$ $a$ x",
+			expected: 10,
+		},
+		{
+			name:     "PositionalInput",
+			query:    "$1 x",
+			expected: 0,
+		},
+		{
+			name:     "DollarInIdentifier",
+			query:    "a$b$ x",
+			idx:      1,
+			expected: 1,
+		},
+		{
+			name:     "LineComment",
+			query:    "-- a\nx",
+			expected: 4,
+		},
+		{
+			name:     "NestedBlockComment",
+			query:    "/* /* */ */ x",
+			expected: 11,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, engine.SkipNonCode(tt.query, tt.idx))
+		})
+	}
+}
@@ -7,10 +7,16 @@ import (
 )
 
 type Store interface {
+	GetColumn(ctx context.Context, params GetColumnParams) (GetColumnRow, error)
 	GetColumnNullability(ctx context.Context, params GetColumnNullabilityParams) (bool, error)
+	GetCompositeAttributesByOID(ctx context.Context, oid uint32) ([]GetCompositeAttributesByOIDRow, error)
 	GetEnumVariantsByOID(ctx context.Context, oid uint32) ([]string, error)
+	GetFunctionByName(ctx context.Context, params GetFunctionByNameParams) (GetFunctionByNameRow, error)
+	GetRangeSubtypeByOID(ctx context.Context, oid uint32) (uint32, error)
 	GetRelationNullability(ctx context.Context, params GetRelationNullabilityParams) ([]bool, error)
 	GetTypeByOID(ctx context.Context, oid uint32) (GetTypeByOIDRow, error)
+	GetTypeOIDByName(ctx context.Context, name string) (uint32, error)
+	GetUniqueIndexColumns(ctx context.Context, params GetUniqueIndexColumnsParams) ([]string, error)
 }
 
 type Querier struct {
@@ -3,6 +3,26 @@ package database
 
 import "context"
 
+type GetColumnParams struct {
+	Relation  uint32
+	Attribute int16
+}
+
+type GetColumnRow struct {
+	Table   string
+	Name    string
+	Type    uint32
+	NotNull bool
+}
+
+func (q *Querier) GetColumn(ctx context.Context, params GetColumnParams) (GetColumnRow, error) {
+	var item GetColumnRow
+	if err := q.db.QueryRow(ctx, "-- :one\n-- $1: relation\n-- $2: attribute\nselect\n    c.relname as \"table\",\n    a.attname as \"name\",\n    a.atttypid as \"type\",\n    a.attnotnull or t.typnotnull as \"not_null\"\nfrom pg_attribute a\njoin pg_class c on c.oid = a.attrelid\njoin pg_type t on t.oid = a.atttypid\nwhere a.attrelid = $1 and a.attnum = $2", params.Relation, params.Attribute).Scan(&item.Table, &item.Name, &item.Type, &item.NotNull); err != nil {
+		return item, err
+	}
+	return item, nil
+}
+
 type GetColumnNullabilityParams struct {
 	Schema     string
 	Relation   string
@@ -17,6 +37,35 @@ func (q *Querier) GetColumnNullability(ctx context.Context, params GetColumnNull
 	return item, nil
 }
 
+type GetCompositeAttributesByOIDRow struct {
+	Name         string
+	Type         uint32
+	NotNull      bool
+	TypeModifier int32
+}
+
+func (q *Querier) GetCompositeAttributesByOID(ctx context.Context, oid uint32) ([]GetCompositeAttributesByOIDRow, error) {
+	rows, err := q.db.Query(ctx, "-- :many\n-- $1: oid\nselect\n    a.attname as \"name\",\n    a.atttypid as \"type\",\n    a.attnotnull or at.typnotnull as \"not_null\",\n    a.atttypmod as \"type_modifier\"\nfrom pg_attribute a\njoin pg_type t on t.typrelid = a.attrelid\njoin pg_type at on at.oid = a.atttypid\nwhere t.oid = $1 and a.attnum > 0 and not a.attisdropped\norder by a.attnum", oid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []GetCompositeAttributesByOIDRow
+	for rows.Next() {
+		var item GetCompositeAttributesByOIDRow
+		if err := rows.Scan(&item.Name, &item.Type, &item.NotNull, &item.TypeModifier); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
 func (q *Querier) GetEnumVariantsByOID(ctx context.Context, oid uint32) ([]string, error) {
 	rows, err := q.db.Query(ctx, "-- :many\n-- $1: oid\nselect enumlabel from pg_enum where enumtypid = $1 order by enumsortorder", oid)
 	if err != nil {
@@ -39,6 +88,34 @@ func (q *Querier) GetEnumVariantsByOID(ctx context.Context, oid uint32) ([]strin
 	return items, nil
 }
 
+type GetFunctionByNameParams struct {
+	Schema string
+	Name   string
+}
+
+type GetFunctionByNameRow struct {
+	Strict        bool
+	ReturnsRecord bool
+	ReturnsSet    bool
+	Aggregate     bool
+}
+
+func (q *Querier) GetFunctionByName(ctx context.Context, params GetFunctionByNameParams) (GetFunctionByNameRow, error) {
+	var item GetFunctionByNameRow
+	if err := q.db.QueryRow(ctx, "-- :one\n-- $1: schema\n-- $2: name\nselect\n    coalesce(bool_and(p.proisstrict), false) as \"strict\",\n    coalesce(bool_or(p.proallargtypes is not null or t.typtype = 'c' or t.oid = 'record'::regtype), false) as \"returns_record\",\n    coalesce(bool_or(p.proretset), false) as \"returns_set\",\n    coalesce(bool_or(p.prokind in ('a', 'w')), false) as \"aggregate\"\nfrom pg_proc p\njoin pg_namespace n on n.oid = p.pronamespace\njoin pg_type t on t.oid = p.prorettype\nwhere ($1 = '' or n.nspname = $1) and p.proname = $2", params.Schema, params.Name).Scan(&item.Strict, &item.ReturnsRecord, &item.ReturnsSet, &item.Aggregate); err != nil {
+		return item, err
+	}
+	return item, nil
+}
+
+func (q *Querier) GetRangeSubtypeByOID(ctx context.Context, oid uint32) (uint32, error) {
+	var item uint32
+	if err := q.db.QueryRow(ctx, "-- :one\n-- $1: oid\nselect rngsubtype from pg_range where rngtypid = $1 or rngmultitypid = $1 limit 1", oid).Scan(&item); err != nil {
+		return item, err
+	}
+	return item, nil
+}
+
 type GetRelationNullabilityParams struct {
 	Schema   string
 	Relation string
@@ -67,15 +144,54 @@ func (q *Querier) GetRelationNullability(ctx context.Context, params GetRelation
 }
 
 type GetTypeByOIDRow struct {
-	Name    string
-	Type    byte
-	NotNull bool
+	Name         string
+	Type         byte
+	NotNull      bool
+	BaseType     uint32
+	ElementType  uint32
+	Category     byte
+	TypeModifier int32
 }
 
 func (q *Querier) GetTypeByOID(ctx context.Context, oid uint32) (GetTypeByOIDRow, error) {
 	var item GetTypeByOIDRow
-	if err := q.db.QueryRow(ctx, "-- :one\n-- $1: oid\nselect\n    typname as \"name\",\n    typtype as \"type\",\n    typnotnull as \"not_null\"\nfrom pg_type where oid = $1 limit 1", oid).Scan(&item.Name, &item.Type, &item.NotNull); err != nil {
+	if err := q.db.QueryRow(ctx, "-- :one\n-- $1: oid\nselect\n    typname as \"name\",\n    typtype as \"type\",\n    typnotnull as \"not_null\",\n    typbasetype as \"base_type\",\n    typelem as \"element_type\",\n    typcategory as \"category\",\n    typtypmod as \"type_modifier\"\nfrom pg_type where oid = $1 limit 1", oid).Scan(&item.Name, &item.Type, &item.NotNull, &item.BaseType, &item.ElementType, &item.Category, &item.TypeModifier); err != nil {
 		return item, err
 	}
 	return item, nil
 }
+
+func (q *Querier) GetTypeOIDByName(ctx context.Context, name string) (uint32, error) {
+	var item uint32
+	if err := q.db.QueryRow(ctx, "-- :one\n-- $1: name\nselect coalesce(to_regtype($1)::oid, 0)", name).Scan(&item); err != nil {
+		return item, err
+	}
+	return item, nil
+}
+
+type GetUniqueIndexColumnsParams struct {
+	Schema string
+	Index  string
+}
+
+func (q *Querier) GetUniqueIndexColumns(ctx context.Context, params GetUniqueIndexColumnsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, "-- :many\n-- $1: schema\n-- $2: index\nselect a.attname\nfrom pg_index i\njoin pg_class c on c.oid = i.indexrelid\njoin pg_namespace n on n.oid = c.relnamespace\ncross join lateral unnest(i.indkey::int2[]) with ordinality as k(attnum, position)\njoin pg_attribute a on a.attrelid = i.indrelid and a.attnum = k.attnum\nwhere n.nspname = $1\n    and c.relname = $2\n    and i.indisunique\n    and i.indpred is null\n    and i.indexprs is null\n    and k.position <= i.indnkeyatts", params.Schema, params.Index)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []string
+	for rows.Next() {
+		var item string
+		if err := rows.Scan(&item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
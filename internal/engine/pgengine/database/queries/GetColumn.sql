@@ -0,0 +1,12 @@
+-- :one
+-- $1: relation
+-- $2: attribute
+select
+    c.relname as "table",
+    a.attname as "name",
+    a.atttypid as "type",
+    a.attnotnull or t.typnotnull as "not_null"
+from pg_attribute a
+join pg_class c on c.oid = a.attrelid
+join pg_type t on t.oid = a.atttypid
+where a.attrelid = $1 and a.attnum = $2
@@ -0,0 +1,12 @@
+-- :many
+-- $1: oid
+select
+    a.attname as "name",
+    a.atttypid as "type",
+    a.attnotnull or at.typnotnull as "not_null",
+    a.atttypmod as "type_modifier"
+from pg_attribute a
+join pg_type t on t.typrelid = a.attrelid
+join pg_type at on at.oid = a.atttypid
+where t.oid = $1 and a.attnum > 0 and not a.attisdropped
+order by a.attnum
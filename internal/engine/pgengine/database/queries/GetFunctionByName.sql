@@ -0,0 +1,12 @@
+-- :one
+-- $1: schema
+-- $2: name
+select
+    coalesce(bool_and(p.proisstrict), false) as "strict",
+    coalesce(bool_or(p.proallargtypes is not null or t.typtype = 'c' or t.oid = 'record'::regtype), false) as "returns_record",
+    coalesce(bool_or(p.proretset), false) as "returns_set",
+    coalesce(bool_or(p.prokind in ('a', 'w')), false) as "aggregate"
+from pg_proc p
+join pg_namespace n on n.oid = p.pronamespace
+join pg_type t on t.oid = p.prorettype
+where ($1 = '' or n.nspname = $1) and p.proname = $2
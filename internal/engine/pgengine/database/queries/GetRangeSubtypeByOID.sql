@@ -0,0 +1,3 @@
+-- :one
+-- $1: oid
+select rngsubtype from pg_range where rngtypid = $1 or rngmultitypid = $1 limit 1
@@ -3,5 +3,9 @@
 select
     typname as "name",
     typtype as "type",
-    typnotnull as "not_null"
+    typnotnull as "not_null",
+    typbasetype as "base_type",
+    typelem as "element_type",
+    typcategory as "category",
+    typtypmod as "type_modifier"
 from pg_type where oid = $1 limit 1
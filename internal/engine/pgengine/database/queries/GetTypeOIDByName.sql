@@ -0,0 +1,3 @@
+-- :one
+-- $1: name
+select coalesce(to_regtype($1)::oid, 0)
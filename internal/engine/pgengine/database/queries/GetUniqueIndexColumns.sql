@@ -0,0 +1,15 @@
+-- :many
+-- $1: schema
+-- $2: index
+select a.attname
+from pg_index i
+join pg_class c on c.oid = i.indexrelid
+join pg_namespace n on n.oid = c.relnamespace
+cross join lateral unnest(i.indkey::int2[]) with ordinality as k(attnum, position)
+join pg_attribute a on a.attrelid = i.indrelid and a.attnum = k.attnum
+where n.nspname = $1
+    and c.relname = $2
+    and i.indisunique
+    and i.indpred is null
+    and i.indexprs is null
+    and k.position <= i.indnkeyatts
@@ -0,0 +1,647 @@
+package pgengine
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/DanielleMaywood/otter/internal/engine"
+)
+
+// functionCall is a function call found in an EXPLAIN output expression,
+// such as `sum(orders.total)` or `count(*) FILTER (WHERE (u.id > 1))`.
+type functionCall struct {
+	Name string
+	Args []string
+
+	// Suffix holds anything following the call's closing parenthesis,
+	// such as a FILTER or OVER clause.
+	Suffix string
+}
+
+// parseFunctionCall parses an output expression as a function call. It
+// reports false when the expression is not a function call.
+func parseFunctionCall(expr string) (functionCall, bool) {
+	name, rest, found := strings.Cut(expr, "(")
+	if !found || name == "" || strings.ContainsAny(name, " \"'()") {
+		return functionCall{}, false
+	}
+
+	end := closingParen(rest)
+	if end < 0 {
+		return functionCall{}, false
+	}
+
+	// Function names may be schema qualified, only the name itself is
+	// interesting to us.
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		name = name[idx+1:]
+	}
+
+	return functionCall{
+		Name:   name,
+		Args:   splitArgs(rest[:end]),
+		Suffix: strings.TrimSpace(rest[end+1:]),
+	}, true
+}
+
+// closingParen returns the index of the parenthesis closing an already
+// opened one, skipping over nested parentheses and quoted strings.
+func closingParen(expr string) int {
+	depth := 0
+
+	for idx := 0; idx < len(expr); idx++ {
+		if end := engine.SkipNonCode(expr, idx); end > idx {
+			idx = end - 1
+			continue
+		}
+
+		switch expr[idx] {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return idx
+			}
+			depth--
+		}
+	}
+
+	return -1
+}
+
+// splitArgs splits a function's arguments on the commas that are not
+// nested inside parentheses or quoted strings.
+func splitArgs(args string) []string {
+	if strings.TrimSpace(args) == "" {
+		return nil
+	}
+
+	var split []string
+	depth, start := 0, 0
+
+	for idx := 0; idx < len(args); idx++ {
+		if end := engine.SkipNonCode(args, idx); end > idx {
+			idx = end - 1
+			continue
+		}
+
+		switch args[idx] {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				split = append(split, strings.TrimSpace(args[start:idx]))
+				start = idx + 1
+			}
+		}
+	}
+
+	return append(split, strings.TrimSpace(args[start:]))
+}
+
+// cutQualifier removes the alias qualifying a column reference, such as
+// `u.id` or `"*SELECT* 1".id`. It reports false when the expression is not
+// qualified by the alias.
+func cutQualifier(expr, alias string) (string, bool) {
+	if columnName, found := strings.CutPrefix(expr, alias+"."); found {
+		return columnName, true
+	}
+
+	return strings.CutPrefix(expr, quoteIdentifier(alias)+".")
+}
+
+// outputColumnName returns the name of the column an output expression
+// references, or an empty string when it is not a column reference.
+func outputColumnName(expr string) string {
+	columnName := expr
+	if idx := strings.LastIndex(expr, "."); idx >= 0 {
+		columnName = expr[idx+1:]
+	}
+
+	if strings.ContainsAny(columnName, "() ") {
+		return ""
+	}
+
+	return columnName
+}
+
+// unquoteIdentifier removes the quotes from a quoted identifier, such as
+// `"User Id"`. It reports false when the expression is not an identifier.
+func unquoteIdentifier(expr string) (string, bool) {
+	if identifierPattern.MatchString(expr) {
+		return expr, true
+	}
+
+	if len(expr) < 2 || expr[0] != '"' || expr[len(expr)-1] != '"' {
+		return "", false
+	}
+
+	name := expr[1 : len(expr)-1]
+	if strings.Contains(strings.ReplaceAll(name, `""`, ""), `"`) {
+		return "", false
+	}
+
+	return strings.ReplaceAll(name, `""`, `"`), true
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// subplanReferences returns the parameters an InitPlan returns its values
+// through, such as `$0` for `InitPlan 1 (returns $0)`. Newer versions of
+// Postgres refer to the InitPlan by name instead.
+func subplanReferences(subplanName string) []string {
+	_, returns, found := strings.Cut(subplanName, "(returns ")
+	if !found {
+		return nil
+	}
+
+	returns = strings.TrimSuffix(returns, ")")
+	return strings.Split(returns, ",")
+}
+
+var subplanReferencePattern = regexp.MustCompile(`^(?:(?:hashed )?SubPlan [0-9]+|alternatives: .+|\((?:SubPlan|InitPlan) [0-9]+\)\.col[0-9]+)$`)
+
+// isSubplanReference reports whether an expression is the value of a
+// SubPlan or InitPlan, such as `SubPlan 1` or `(InitPlan 1).col1`, once
+// any parentheses wrapping it are removed.
+func isSubplanReference(expr string) bool {
+	return subplanReferencePattern.MatchString(expr)
+}
+
+var (
+	parameterPattern  = regexp.MustCompile(`^\$[0-9]+$`)
+	identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+	literalPattern    = regexp.MustCompile(`^(-?[0-9]+(\.[0-9]+)?(e[-+]?[0-9]+)?|true|false|'(?:[^']|'')*')$`)
+	operatorPattern   = regexp.MustCompile("^[-+*/<>=~!@#%^&|`?]+$")
+	castTypePattern   = regexp.MustCompile(`^[A-Za-z0-9_ ."\[\](),:]+$`)
+)
+
+// parameterName returns the parameter an expression refers to, looking
+// through any parentheses or type casts such as `($1)::text`. It reports
+// false when the expression is not a parameter.
+func parameterName(expr string) (string, bool) {
+	expr = stripCasts(expr)
+	return expr, parameterPattern.MatchString(expr)
+}
+
+// stripCasts removes any parentheses and type casts wrapping the whole of
+// an expression, such as `(($1)::text)::varchar`.
+func stripCasts(expr string) string {
+	for {
+		expr = stripParens(strings.TrimSpace(expr))
+
+		operand, isCast := cutCast(expr)
+		if !isCast {
+			return expr
+		}
+
+		expr = operand
+	}
+}
+
+// valuesColumn returns the index of the column of a VALUES list that an
+// output of a Values Scan refers to, such as 1 for `"*VALUES*".column2`.
+// It reports false when the output is not a column of the VALUES list.
+func valuesColumn(expr, alias string) (int, bool) {
+	column, isColumn := cutQualifier(stripCasts(expr), alias)
+	if !isColumn {
+		return 0, false
+	}
+
+	number, found := strings.CutPrefix(column, "column")
+	if !found {
+		return 0, false
+	}
+
+	position, err := strconv.Atoi(number)
+	if err != nil || position < 1 {
+		return 0, false
+	}
+
+	return position - 1, true
+}
+
+// isLiteral reports whether an expression is a non-null constant, such as
+// `1`, `true` or `'abc'`.
+func isLiteral(expr string) bool {
+	return literalPattern.MatchString(expr)
+}
+
+// isOperator reports whether a token is an operator, such as `+` or `||`.
+func isOperator(token string) bool {
+	return operatorPattern.MatchString(token)
+}
+
+// stripParens removes any parentheses wrapping the whole of an expression,
+// such as `((u.id + 1))`.
+func stripParens(expr string) string {
+	for strings.HasPrefix(expr, "(") && closingParen(expr[1:]) == len(expr)-2 {
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+
+	return expr
+}
+
+// cutCast removes a type cast from an expression, such as `(u.id)::text`.
+// It reports false when the expression is not a type cast.
+func cutCast(expr string) (string, bool) {
+	depth := 0
+	var quote rune
+
+	for idx, r := range expr {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			depth--
+		case r == ':' && depth == 0 && strings.HasPrefix(expr[idx:], "::"):
+			// Anything other than a type name following the cast means
+			// the cast only applies to part of the expression.
+			if idx == 0 || !isTypeName(expr[idx+2:]) {
+				return "", false
+			}
+
+			return expr[:idx], true
+		}
+	}
+
+	return "", false
+}
+
+// isTypeName reports whether an expression is the name of a type, such as
+// `text`, `numeric(10,2)[]` or `timestamp with time zone`.
+func isTypeName(expr string) bool {
+	if !castTypePattern.MatchString(expr) {
+		return false
+	}
+
+	// A few type names are made up of several words. Any other words
+	// following the type belong to the surrounding expression.
+	tokens := splitTokens(expr)
+	for _, token := range tokens[1:] {
+		switch strings.TrimSuffix(token, "[]") {
+		case "varying", "precision", "with", "without", "time", "zone":
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// splitTokens splits an expression on the whitespace that is not nested
+// inside parentheses, brackets or quoted strings.
+func splitTokens(expr string) []string {
+	var tokens []string
+	depth, start := 0, 0
+	var quote rune
+
+	for idx, r := range expr {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			depth--
+		case r == ' ' && depth == 0:
+			if start < idx {
+				tokens = append(tokens, expr[start:idx])
+			}
+			start = idx + 1
+		}
+	}
+
+	if start < len(expr) {
+		tokens = append(tokens, expr[start:])
+	}
+
+	return tokens
+}
+
+// caseResults returns the expressions a CASE expression may evaluate to,
+// and whether it has an ELSE. It reports false when the expression is not
+// a CASE expression.
+func caseResults(expr string) (results []string, hasElse bool, isCase bool) {
+	tokens := splitTokens(expr)
+	if len(tokens) < 2 || tokens[0] != "CASE" || tokens[len(tokens)-1] != "END" {
+		return nil, false, false
+	}
+
+	// Nested CASE expressions are not wrapped in parentheses, so their
+	// keywords are skipped over by tracking how deeply nested we are.
+	depth := 0
+	var result []string
+	inResult := false
+
+	for _, token := range tokens[1 : len(tokens)-1] {
+		switch {
+		case token == "CASE":
+			depth++
+		case token == "END":
+			depth--
+		case depth == 0 && slices.Contains([]string{"WHEN", "THEN", "ELSE"}, token):
+			if inResult {
+				results = append(results, strings.Join(result, " "))
+			}
+
+			result = nil
+			inResult = token != "WHEN"
+			hasElse = hasElse || token == "ELSE"
+			continue
+		}
+
+		result = append(result, token)
+	}
+
+	if inResult {
+		results = append(results, strings.Join(result, " "))
+	}
+
+	return results, hasElse, true
+}
+
+// equalityColumns returns the columns of the alias that a condition fixes
+// to a single value, such as `id` in `((u.id = $1) AND (u.age > 18))`.
+func equalityColumns(cond, alias string) []string {
+	var columns []string
+
+	// Conjuncts are each wrapped in parentheses, so a condition without
+	// a top-level AND is a single conjunct.
+	conjuncts := []string{cond}
+	if tokens := splitTokens(stripParens(cond)); slices.Contains(tokens, "AND") {
+		conjuncts = slices.DeleteFunc(tokens, func(token string) bool {
+			return token == "AND"
+		})
+	}
+
+	for _, conjunct := range conjuncts {
+		tokens := splitTokens(stripParens(conjunct))
+		if len(tokens) != 3 || tokens[1] != "=" {
+			continue
+		}
+
+		for _, operand := range []string{tokens[0], tokens[2]} {
+			operand = stripParens(operand)
+			if uncast, isCast := cutCast(operand); isCast {
+				operand = stripParens(uncast)
+			}
+
+			if columnName, isQualified := cutQualifier(operand, alias); isQualified {
+				operand = columnName
+			}
+
+			if columnName, isColumn := unquoteIdentifier(operand); isColumn {
+				columns = append(columns, columnName)
+			}
+		}
+	}
+
+	return columns
+}
+
+// assignment is a column assigned a value in the SET list of an UPDATE, an
+// ON CONFLICT DO UPDATE or the UPDATE action of a MERGE, such as `name =
+// $1`.
+type assignment struct {
+	Column string
+	Value  string
+}
+
+// parseAssignments finds the assignments made by every SET list in a
+// query. Assignments to several columns at once, such as `(a, b) = ($1,
+// $2)`, are left out.
+func parseAssignments(query string) []assignment {
+	query = engine.StripComments(query)
+
+	var assignments []assignment
+	for _, list := range setLists(query) {
+		for _, item := range splitArgs(list) {
+			column, value, found := strings.Cut(item, "=")
+			if !found {
+				continue
+			}
+
+			column, isColumn := unquoteIdentifier(strings.TrimSpace(column))
+			if !isColumn {
+				continue
+			}
+
+			assignments = append(assignments, assignment{
+				Column: column,
+				Value:  strings.TrimSpace(value),
+			})
+		}
+	}
+
+	return assignments
+}
+
+// setLists returns the contents of every SET list in a query, ending at
+// the clause that follows it, the next WHEN clause of a MERGE or the
+// parenthesis closing the statement it belongs to.
+func setLists(query string) []string {
+	var lists []string
+
+	// CASE expressions are tracked so that their WHEN is not mistaken for
+	// the WHEN clause of a MERGE.
+	start, depth, caseDepth := -1, 0, 0
+
+	for idx := 0; idx < len(query); idx++ {
+		if end := engine.SkipNonCode(query, idx); end > idx {
+			idx = end - 1
+			continue
+		}
+
+		switch {
+		case query[idx] == '(':
+			depth++
+		case query[idx] == ')':
+			depth--
+			if start >= 0 && depth < 0 {
+				lists = append(lists, query[start:idx])
+				start = -1
+			}
+		case query[idx] == ';':
+			if start >= 0 {
+				lists = append(lists, query[start:idx])
+				start = -1
+			}
+		case isKeywordAt(query, idx, "set"):
+			if start >= 0 {
+				lists = append(lists, query[start:idx])
+			}
+			start, depth, caseDepth = idx+len("set"), 0, 0
+		case start >= 0 && isKeywordAt(query, idx, "case"):
+			caseDepth++
+		case start >= 0 && caseDepth > 0 && isKeywordAt(query, idx, "end"):
+			caseDepth--
+		case start >= 0 && depth == 0 && caseDepth == 0 && isKeywordAt(query, idx, "when"):
+			lists = append(lists, query[start:idx])
+			start = -1
+		case start >= 0 && depth == 0 && (isKeywordAt(query, idx, "where") || isKeywordAt(query, idx, "from") || isKeywordAt(query, idx, "returning")):
+			lists = append(lists, query[start:idx])
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		lists = append(lists, query[start:])
+	}
+
+	return lists
+}
+
+// insertValues is the VALUES list of an INSERT, such as `insert into users
+// (id, name) values ($1, $2), ($3, $4)`, split into its rows of values.
+type insertValues struct {
+	Relation string
+	Rows     [][]string
+}
+
+// parseInsertValues finds the VALUES list of every INSERT in a query. An
+// INSERT taking its rows from a SELECT or inserting DEFAULT VALUES has
+// none.
+func parseInsertValues(query string) []insertValues {
+	query = engine.StripComments(query)
+
+	var inserts []insertValues
+
+	// The VALUES of an INSERT sit at the same depth as the INSERT itself,
+	// unlike any VALUES within the subqueries of an INSERT ... SELECT.
+	relation, insertDepth, depth := "", 0, 0
+
+	for idx := 0; idx < len(query); idx++ {
+		if end := engine.SkipNonCode(query, idx); end > idx {
+			idx = end - 1
+			continue
+		}
+
+		switch {
+		case query[idx] == '(':
+			depth++
+		case query[idx] == ')':
+			depth--
+			if depth < insertDepth {
+				relation = ""
+			}
+		case query[idx] == ';':
+			relation = ""
+		case isKeywordAt(query, idx, "insert"):
+			if name, isInsert := insertRelation(query[idx+len("insert"):]); isInsert {
+				relation, insertDepth = name, depth
+			}
+		case relation != "" && depth == insertDepth && (isKeywordAt(query, idx, "select") || isKeywordAt(query, idx, "default")):
+			relation = ""
+		case relation != "" && depth == insertDepth && isKeywordAt(query, idx, "values"):
+			rows, end := valuesRows(query, idx+len("values"))
+			inserts = append(inserts, insertValues{Relation: relation, Rows: rows})
+			relation = ""
+			idx = end - 1
+		}
+	}
+
+	return inserts
+}
+
+// insertRelation returns the name of the relation that follows the INSERT
+// keyword, such as `users` in `insert into public.users (id)`. It reports
+// false for the INSERT action of a MERGE, which has no relation of its own.
+func insertRelation(rest string) (string, bool) {
+	rest = strings.TrimLeft(rest, " \t\r\n")
+	if !isKeywordAt(rest, 0, "into") {
+		return "", false
+	}
+	rest = strings.TrimLeft(rest[len("into"):], " \t\r\n")
+
+	// The relation ends at the column list, an alias or anything else
+	// following it, and only its last part names the relation itself.
+	start, end := 0, len(rest)
+	for idx := 0; idx < len(rest); idx++ {
+		if skip := engine.SkipNonCode(rest, idx); skip > idx {
+			idx = skip - 1
+			continue
+		}
+
+		if rest[idx] == '.' {
+			start = idx + 1
+		} else if rest[idx] == '(' || strings.IndexByte(" \t\r\n", rest[idx]) >= 0 {
+			end = idx
+			break
+		}
+	}
+
+	name := rest[start:end]
+	if !strings.HasPrefix(name, `"`) {
+		name = strings.ToLower(name)
+	}
+
+	return unquoteIdentifier(name)
+}
+
+// valuesRows splits the rows of a VALUES list starting at the given index
+// of the query, returning them along with the index the list ends at.
+func valuesRows(query string, idx int) ([][]string, int) {
+	var rows [][]string
+
+	skipSpace := func(idx int) int {
+		for idx < len(query) && strings.IndexByte(" \t\r\n", query[idx]) >= 0 {
+			idx++
+		}
+		return idx
+	}
+
+	for {
+		idx = skipSpace(idx)
+		if idx >= len(query) || query[idx] != '(' {
+			return rows, idx
+		}
+
+		end := closingParen(query[idx+1:])
+		if end < 0 {
+			return rows, len(query)
+		}
+
+		rows = append(rows, splitArgs(query[idx+1:idx+1+end]))
+		idx = skipSpace(idx + end + 2)
+		if idx >= len(query) || query[idx] != ',' {
+			return rows, idx
+		}
+		idx++
+	}
+}
+
+// isKeywordAt reports whether the keyword appears as a whole word at the
+// given index of the query.
+func isKeywordAt(query string, idx int, keyword string) bool {
+	if len(query)-idx < len(keyword) || !strings.EqualFold(query[idx:idx+len(keyword)], keyword) {
+		return false
+	}
+
+	isWordByte := func(b byte) bool {
+		return b == '_' || b == '$' || b == '.' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+	}
+
+	if idx > 0 && isWordByte(query[idx-1]) {
+		return false
+	}
+
+	end := idx + len(keyword)
+	return end == len(query) || !isWordByte(query[end])
+}
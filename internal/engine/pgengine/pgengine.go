@@ -3,12 +3,17 @@ package pgengine
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/DanielleMaywood/otter/internal/engine"
 	"github.com/DanielleMaywood/otter/internal/engine/pgengine/database"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type Engine struct {
@@ -22,45 +27,104 @@ func New(conn *pgx.Conn) Engine {
 	return Engine{conn: conn, store: database.New(conn)}
 }
 
-func (e Engine) ResolveQueries(ctx context.Context, queries map[string]string) (engine.Result, error) {
+func (e Engine) ResolveQueries(ctx context.Context, queries map[string]engine.QuerySource) (engine.Result, error) {
 	typeMap := make(map[string]engine.Type)
 
 	result := engine.Result{
 		Queries: make(map[string]engine.Query),
 	}
 
-	for queryName, query := range queries {
+	for queryName, source := range queries {
+		// Named parameters are rewritten into positional ones before
+		// anything else, so that the rest of otter only ever has to deal
+		// with positional parameters.
+		query, inputNames := engine.RewriteNamedInputs(source.SQL)
+
 		var queryType engine.Query
 		queryType.Name = queryName
-		queryType.SQL = strings.TrimSpace(query)
 
-		preparedQuery, err := e.conn.Prepare(ctx, queryName, query)
+		header, err := engine.ParseHeader(source.File, source.Line, query)
+		if err != nil {
+			return result, fmt.Errorf("parse query '%s' header: %w", queryName, err)
+		}
+
+		inputAnnotations := header.Inputs
+		for arg, name := range inputNames {
+			input := inputAnnotations[arg]
+			if input.Name == "" {
+				input.Name = name
+			}
+
+			inputAnnotations[arg] = input
+		}
+
+		preparedQuery, err := e.prepareQuery(ctx, queryName, query, inputAnnotations)
 		if err != nil {
 			return result, fmt.Errorf("prepare query '%s': %w", queryName, err)
 		}
 
-		queryPlan, err := e.explainQuery(ctx, query)
+		// The casts are kept in the generated SQL as well, as otherwise
+		// Postgres would infer the parameters' types from the query alone.
+		castQuery := castInputs(query, inputAnnotations)
+		queryType.SQL = strings.TrimSpace(castQuery)
+
+		queryPlan, err := e.explainQuery(ctx, castQuery)
 		if err != nil {
 			return result, fmt.Errorf("explain query '%s': %w", queryName, err)
 		}
 
-		switch queryPlan.Rows {
-		case 0:
+		singleRow, err := e.isSingleRow(ctx, queryPlan)
+		if err != nil {
+			return result, fmt.Errorf("compute query '%s' cardinality: %w", queryName, err)
+		}
+
+		// Queries that cannot return any rows are assumed to be exec
+		// queries, and those that can return at most one row are assumed
+		// to be one queries, unless the header explicitly says otherwise.
+		switch {
+		case header.Type != "":
+			queryType.Type = header.Type
+		case queryPlan.Rows == 0:
 			queryType.Type = engine.QueryTypeExec
+		case singleRow:
+			queryType.Type = engine.QueryTypeOne
 		default:
-			queryType.Type = engine.ParseQueryType(query)
+			queryType.Type = engine.QueryTypeMany
 		}
 
-		inputNames := engine.ParseQueryInputNames(query)
+		// A statement without any columns has no rows to scan, so
+		// there is nothing that a :one or :many query could return.
+		if (queryType.Type == engine.QueryTypeOne || queryType.Type == engine.QueryTypeMany) && len(preparedQuery.Fields) == 0 {
+			return result, fmt.Errorf("query '%s' is annotated :%s but does not return any columns", queryName, queryType.Type)
+		}
+
+		if queryType.Type == engine.QueryTypeOne && !singleRow {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("query '%s' is annotated :one but may return more than one row", queryName))
+		}
 
-		inputNullabilityMap, outputNullabilityMap, err := e.computeNullability(ctx, queryPlan)
+		inputNullabilityMap, outputNullabilityMap, err := e.computeNullability(ctx, queryPlan, nil)
 		if err != nil {
 			return result, fmt.Errorf("compute nullable inputs: %w", err)
 		}
 
+		assignedNullabilityMap, err := e.computeAssignmentNullability(ctx, query, queryPlan)
+		if err != nil {
+			return result, fmt.Errorf("compute nullable assignments: %w", err)
+		}
+
+		for input, nullable := range assignedNullabilityMap {
+			inputNullabilityMap[input] = inputNullabilityMap[input] || nullable
+		}
+
 		inputNullability := make([]bool, len(preparedQuery.ParamOIDs))
 		for idx := range preparedQuery.ParamOIDs {
 			inputNullability[idx] = inputNullabilityMap[fmt.Sprintf("$%d", idx+1)]
+
+			// An annotation in the header always wins over what we
+			// were able to infer.
+			if nullable := inputAnnotations[fmt.Sprint(idx+1)].Nullable; nullable != nil {
+				inputNullability[idx] = *nullable
+			}
 		}
 
 		outputNullability := make([]bool, len(queryPlan.Output))
@@ -78,10 +142,18 @@ func (e Engine) ResolveQueries(ctx context.Context, queries map[string]string) (
 			}
 
 			inputType.Nullable = nullable
-			typeMap[inputType.Name] = inputType
+
+			if elemNullable := inputAnnotations[fmt.Sprint(idx+1)].ElemNullable; elemNullable != nil {
+				inputType, err = withElemNullability(inputType, *elemNullable)
+				if err != nil {
+					return result, fmt.Errorf("parameter '$%d': %w", idx+1, err)
+				}
+			}
+
+			registerType(typeMap, inputType)
 
 			queryType.Inputs[idx] = engine.Input{
-				Name: inputNames[fmt.Sprint(idx+1)],
+				Name: inputAnnotations[fmt.Sprint(idx+1)].Name,
 				Type: inputType,
 			}
 		}
@@ -90,36 +162,144 @@ func (e Engine) ResolveQueries(ctx context.Context, queries map[string]string) (
 		for idx, field := range preparedQuery.Fields {
 			nullable := outputNullability[idx]
 
-			outputType, err := e.resolveType(ctx, field.DataTypeOID, &nullable)
-			if err != nil {
-				return result, fmt.Errorf("resolve type '%d': %w", field.DataTypeOID, err)
+			// Columns that come straight from a table are described by
+			// their table OID and attribute number, which lets us read
+			// their nullability from the catalog rather than matching
+			// them up with the plan. The plan is still consulted as an
+			// outer join can introduce nulls into a not null column.
+			//
+			// Postgres also describes domain columns using their base
+			// type, so we take the declared type of the column to keep
+			// hold of the domain.
+			//
+			// A whole row reference is described by the table OID with
+			// an attribute number of zero, and is not a column.
+			typeOID := field.DataTypeOID
+			var columnName string
+			if field.TableOID != 0 && field.TableAttributeNumber > 0 {
+				column, err := e.store.GetColumn(ctx, database.GetColumnParams{
+					Relation:  field.TableOID,
+					Attribute: int16(field.TableAttributeNumber),
+				})
+				if err != nil {
+					return result, fmt.Errorf("get column '%s': %w", field.Name, err)
+				}
+
+				typeOID = column.Type
+				columnName = column.Table + "." + column.Name
+				nullable = nullable || !column.NotNull
+			}
+
+			// Postgres names unaliased expressions `?column?`, which is
+			// not to be mistaken for an alias ending in `?`.
+			var outputName string
+			var forceNullable, forceElemNullable *bool
+			if field.Name != "?column?" {
+				outputName, forceNullable, forceElemNullable = engine.ParseOutputName(field.Name)
+			}
+
+			// An alias ending in `!` or `?` always wins over what we were
+			// able to infer.
+			if forceNullable != nil {
+				nullable = *forceNullable
 			}
 
-			typeMap[outputType.Name] = outputType
+			outputType, err := e.resolveType(ctx, typeOID, &nullable)
+			if err != nil {
+				return result, fmt.Errorf("resolve type '%d': %w", typeOID, err)
+			}
 
-			outputName := field.Name
-			if outputName == "?column?" {
-				outputName = ""
+			if forceElemNullable != nil {
+				outputType, err = withElemNullability(outputType, *forceElemNullable)
+				if err != nil {
+					return result, fmt.Errorf("output '%s': %w", outputName, err)
+				}
 			}
 
+			outputType = withTypeModifier(outputType, field.TypeModifier)
+			registerType(typeMap, outputType)
+
 			queryType.Outputs[idx] = engine.Output{
-				Name: outputName,
-				Type: outputType,
+				Name:   outputName,
+				Type:   outputType,
+				Column: columnName,
 			}
 		}
 
 		result.Queries[queryName] = queryType
 	}
 
+	slices.Sort(result.Warnings)
+
+	// The models are shared between every use of a type, so they do not
+	// carry the nullability or modifiers of any one use.
 	result.Types = make([]engine.Type, 0, len(typeMap))
 	for _, typ := range typeMap {
 		typ.Nullable = false
+		typ.Precision, typ.Scale = 0, 0
 		result.Types = append(result.Types, typ)
 	}
 
 	return result, nil
 }
 
+// prepareQuery prepares the query, checking that every parameter annotated
+// in the query's header exists. Parameters annotated with a type are given
+// that type rather than the one Postgres would infer, which lets a query
+// use a parameter that Postgres has no way of inferring the type of.
+func (e Engine) prepareQuery(ctx context.Context, queryName, query string, inputs map[string]engine.InputAnnotation) (*pgconn.StatementDescription, error) {
+	// A query has as many parameters as the highest position it uses.
+	inputCount := 0
+	for _, position := range engine.PositionalInputs(query) {
+		inputCount = max(inputCount, position)
+	}
+
+	paramOIDs := make([]uint32, inputCount)
+	hasTypes := false
+
+	for arg, input := range inputs {
+		idx, err := strconv.Atoi(arg)
+		if err != nil || idx < 1 || idx > inputCount {
+			return nil, fmt.Errorf("annotation '$%s' does not match any parameter of the query", arg)
+		}
+
+		if input.Type == "" {
+			continue
+		}
+
+		oid, err := e.store.GetTypeOIDByName(ctx, input.Type)
+		if err != nil {
+			return nil, fmt.Errorf("get type '%s': %w", input.Type, err)
+		} else if oid == 0 {
+			return nil, fmt.Errorf("annotation '$%s' has unknown type '%s'", arg, input.Type)
+		}
+
+		paramOIDs[idx-1] = oid
+		hasTypes = true
+	}
+
+	if !hasTypes {
+		return e.conn.Prepare(ctx, queryName, query)
+	}
+
+	// Parameters left as zero are still inferred by Postgres.
+	return e.conn.PgConn().Prepare(ctx, "", query, paramOIDs)
+}
+
+// castInputs casts the parameters annotated with a type to that type. The
+// EXPLAIN of a query is sent without a separate list of parameter types, so
+// this is how it learns them. Postgres gives a parameter the type of its
+// first cast, which leaves no trace of the cast in the plan.
+func castInputs(query string, inputs map[string]engine.InputAnnotation) string {
+	return engine.RewritePositionalInputs(query, func(position int) string {
+		if typ := inputs[strconv.Itoa(position)].Type; typ != "" {
+			return fmt.Sprintf("($%d::%s)", position, typ)
+		}
+
+		return fmt.Sprintf("$%d", position)
+	})
+}
+
 func (e Engine) resolveType(ctx context.Context, oid uint32, nullable *bool) (engine.Type, error) {
 	typeInfo, err := e.store.GetTypeByOID(ctx, oid)
 	if err != nil {
@@ -133,6 +313,26 @@ func (e Engine) resolveType(ctx context.Context, oid uint32, nullable *bool) (en
 	switch typeInfo.Type {
 	// Base
 	case 'b':
+		// Arrays are base types in the 'A' category. Postgres has no
+		// notion of element nullability, and even a not null array can
+		// hold nulls, so elements are assumed to be nullable unless an
+		// annotation says otherwise.
+		if typeInfo.Category == 'A' && typeInfo.ElementType != 0 {
+			elemNullable := true
+
+			elemType, err := e.resolveType(ctx, typeInfo.ElementType, &elemNullable)
+			if err != nil {
+				return engine.Type{}, fmt.Errorf("resolve element type: %w", err)
+			}
+
+			return engine.Type{
+				Kind:     engine.TypeKindArray,
+				Name:     typeInfo.Name,
+				Elem:     &elemType,
+				Nullable: !typeInfo.NotNull,
+			}, nil
+		}
+
 		return engine.Type{
 			Kind:     engine.TypeKindBase,
 			Name:     typeInfo.Name,
@@ -153,9 +353,148 @@ func (e Engine) resolveType(ctx context.Context, oid uint32, nullable *bool) (en
 			Nullable: !typeInfo.NotNull,
 		}, nil
 
+	// Composite
+	case 'c':
+		attributes, err := e.store.GetCompositeAttributesByOID(ctx, oid)
+		if err != nil {
+			return engine.Type{}, fmt.Errorf("get attributes: %w", err)
+		}
+
+		compositeType := engine.Type{
+			Kind:       engine.TypeKindComposite,
+			Name:       typeInfo.Name,
+			Attributes: make([]engine.Attribute, len(attributes)),
+			Nullable:   !typeInfo.NotNull,
+		}
+
+		for idx, attribute := range attributes {
+			nullable := !attribute.NotNull
+
+			attributeType, err := e.resolveType(ctx, attribute.Type, &nullable)
+			if err != nil {
+				return engine.Type{}, fmt.Errorf("resolve attribute '%s': %w", attribute.Name, err)
+			}
+
+			compositeType.Attributes[idx] = engine.Attribute{
+				Name: attribute.Name,
+				Type: withTypeModifier(attributeType, attribute.TypeModifier),
+			}
+		}
+
+		return compositeType, nil
+
+	// Domain
+	case 'd':
+		baseNullable := false
+
+		baseType, err := e.resolveType(ctx, typeInfo.BaseType, &baseNullable)
+		if err != nil {
+			return engine.Type{}, fmt.Errorf("resolve base type: %w", err)
+		}
+
+		baseType = withTypeModifier(baseType, typeInfo.TypeModifier)
+
+		return engine.Type{
+			Kind:     engine.TypeKindDomain,
+			Name:     typeInfo.Name,
+			Base:     &baseType,
+			Nullable: !typeInfo.NotNull,
+		}, nil
+
+	// Range, Multirange
+	case 'r', 'm':
+		subtypeOID, err := e.store.GetRangeSubtypeByOID(ctx, oid)
+		if err != nil {
+			return engine.Type{}, fmt.Errorf("get range subtype: %w", err)
+		}
+
+		subtypeNullable := false
+
+		subtype, err := e.resolveType(ctx, subtypeOID, &subtypeNullable)
+		if err != nil {
+			return engine.Type{}, fmt.Errorf("resolve range subtype: %w", err)
+		}
+
+		kind := engine.TypeKindRange
+		if typeInfo.Type == 'm' {
+			kind = engine.TypeKindMultirange
+		}
+
+		return engine.Type{
+			Kind:     kind,
+			Name:     typeInfo.Name,
+			Elem:     &subtype,
+			Nullable: !typeInfo.NotNull,
+		}, nil
+
 	default:
-		return engine.Type{}, fmt.Errorf("unsupported type: %b", typeInfo.Type)
+		return engine.Type{}, fmt.Errorf("unsupported type: %c", typeInfo.Type)
+	}
+}
+
+// withTypeModifier returns the type with the precision and scale that a
+// numeric type modifier declares. Arrays are declared with the modifier of
+// their elements.
+func withTypeModifier(typ engine.Type, typeModifier int32) engine.Type {
+	// Postgres offsets the modifier by the size of a varlena header, and
+	// uses -1 when there is no modifier.
+	const varlenaHeaderSize = 4
+	if typeModifier < varlenaHeaderSize {
+		return typ
+	}
+
+	switch {
+	case typ.Kind == engine.TypeKindArray:
+		elem := withTypeModifier(*typ.Elem, typeModifier)
+		typ.Elem = &elem
+
+	case typ.Kind == engine.TypeKindBase && typ.Name == "numeric":
+		// The precision is held in the upper 16 bits, and the scale in
+		// the lower 11 bits as a signed number.
+		typeModifier -= varlenaHeaderSize
+		typ.Precision = int(typeModifier>>16) & 0xffff
+		typ.Scale = (int(typeModifier)&0x7ff ^ 1024) - 1024
+	}
+
+	return typ
+}
+
+// withElemNullability returns the array type with the nullability of its
+// elements set by an annotation.
+func withElemNullability(typ engine.Type, nullable bool) (engine.Type, error) {
+	if typ.Kind != engine.TypeKindArray {
+		return typ, fmt.Errorf("element nullability annotated on type '%s', which is not an array", typ.Name)
 	}
+
+	elem := *typ.Elem
+	elem.Nullable = nullable
+	typ.Elem = &elem
+
+	return typ, nil
+}
+
+// registerType adds the type, and every type it is built from, to the
+// type map so that they are all emitted as models. Arrays and ranges have
+// no model of their own, only their element type is registered.
+func registerType(typeMap map[string]engine.Type, typ engine.Type) {
+	for _, attribute := range typ.Attributes {
+		registerType(typeMap, attribute.Type)
+	}
+
+	if typ.Base != nil {
+		registerType(typeMap, *typ.Base)
+	}
+
+	if typ.Elem != nil {
+		registerType(typeMap, *typ.Elem)
+	}
+
+	switch typ.Kind {
+	case engine.TypeKindArray, engine.TypeKindRange, engine.TypeKindMultirange:
+		return
+	}
+
+	typeMap[typ.Name] = typ
 }
 
 type queryExplain struct {
@@ -172,6 +511,25 @@ type queryPlan struct {
 	Schema    string      `json:"Schema"`
 	Relation  string      `json:"Relation Name"`
 	Rows      int         `json:"Plan Rows"`
+	Strategy  string      `json:"Strategy"`
+
+	// GroupingSets is only used to tell whether an aggregate has grouping
+	// sets, so the sets themselves are left undecoded.
+	GroupingSets []json.RawMessage `json:"Grouping Sets"`
+
+	ParentRelationship string `json:"Parent Relationship"`
+	SubplanName        string `json:"Subplan Name"`
+	CTEName            string `json:"CTE Name"`
+	FunctionName       string `json:"Function Name"`
+	FunctionCall       string `json:"Function Call"`
+	ConflictResolution string `json:"Conflict Resolution"`
+	IndexName          string `json:"Index Name"`
+	IndexCond          string `json:"Index Cond"`
+
+	// initPlanParams are the parameters that InitPlans return their
+	// values through before Postgres 17, such as `$0`, which this node
+	// can read.
+	initPlanParams map[string]bool
 }
 
 func (e Engine) explainQuery(ctx context.Context, query string) (queryPlan, error) {
@@ -206,57 +564,211 @@ func (e Engine) explainQuery(ctx context.Context, query string) (queryPlan, erro
 	return explains[0].Plan, nil
 }
 
-func (e Engine) computeNullability(ctx context.Context, plan queryPlan) (map[string]bool, map[string]bool, error) {
+// isSingleRow reports whether the plan provably returns at most one row.
+// This is the case for a plain aggregate, a scan on a unique index that
+// fixes every column of the index, or anything built from only those. The
+// count of a limit is not part of the plan, so a `limit 1` cannot be told
+// apart from any other limit and needs a `-- :one` header.
+func (e Engine) isSingleRow(ctx context.Context, plan queryPlan) (bool, error) {
+	// InitPlans and SubPlans do not add to the rows of their parent.
+	children := make([]queryPlan, 0, len(plan.Plans))
+	for _, child := range plan.Plans {
+		if child.ParentRelationship != "InitPlan" && child.ParentRelationship != "SubPlan" {
+			children = append(children, child)
+		}
+	}
+
 	switch plan.NodeType {
 	case "Result":
-		return make(map[string]bool), make(map[string]bool), nil
+		if len(children) == 0 {
+			return true, nil
+		}
 
-	case "Hash", "Limit", "Sort", "Materialize":
-		return e.computeNullability(ctx, plan.Plans[0])
+		return e.isSingleRow(ctx, children[0])
 
-	case "ModifyTable":
-		switch plan.Operation {
-		case "Insert":
-			inputs, outputs, err := e.computeNullability(ctx, plan.Plans[0])
-			if err != nil {
-				return nil, nil, err
+	case "Aggregate":
+		return plan.Strategy == "Plain", nil
+
+	case "Limit", "Sort", "Materialize", "Unique", "ModifyTable", "Subquery Scan", "Hash":
+		if len(children) == 0 {
+			return false, nil
+		}
+
+		return e.isSingleRow(ctx, children[0])
+
+	case "Index Scan", "Index Only Scan":
+		columns, err := e.store.GetUniqueIndexColumns(ctx, database.GetUniqueIndexColumnsParams{
+			Schema: plan.Schema,
+			Index:  plan.IndexName,
+		})
+		if err != nil {
+			return false, fmt.Errorf("get unique index '%s' columns: %w", plan.IndexName, err)
+		} else if len(columns) == 0 {
+			return false, nil
+		}
+
+		fixed := equalityColumns(plan.IndexCond, plan.Alias)
+		for _, column := range columns {
+			if !slices.Contains(fixed, column) {
+				return false, nil
 			}
-			if plan.Plans[0].NodeType != "Result" {
-				return inputs, outputs, nil
+		}
+
+		return true, nil
+
+	case "Hash Join", "Merge Join", "Nested Loop":
+		// A join returns at most one row when it joins a single row to at
+		// most one row. Semi and Anti joins return at most the rows of
+		// one side.
+		if len(children) != 2 {
+			return false, nil
+		}
+
+		switch plan.JoinType {
+		case "Semi", "Anti":
+			return e.isSingleRow(ctx, children[0])
+		case "Right Semi", "Right Anti":
+			return e.isSingleRow(ctx, children[1])
+		}
+
+		for _, child := range children {
+			singleRow, err := e.isSingleRow(ctx, child)
+			if err != nil || !singleRow {
+				return false, err
 			}
+		}
 
-			resultPlan := plan.Plans[0]
+		return true, nil
 
-			nullability, err := e.store.GetRelationNullability(ctx, database.GetRelationNullabilityParams{
-				Schema:   plan.Schema,
-				Relation: plan.Relation,
-			})
+	default:
+		return false, nil
+	}
+}
 
-			for idx, name := range resultPlan.Output {
-				if nullability[idx] {
-					inputs[name] = true
-				}
+// cteNullability holds the already computed nullability of a CTE, for
+// the CTE scans reading from it.
+type cteNullability struct {
+	plan    queryPlan
+	outputs map[string]bool
+}
+
+func (e Engine) computeNullability(ctx context.Context, plan queryPlan, ctes map[string]cteNullability) (map[string]bool, map[string]bool, error) {
+	inputs := make(map[string]bool)
+	initPlanParams := make(map[string]bool)
+
+	// InitPlans and SubPlans sit alongside a node's children. They are
+	// separated out so that the node only sees its real children.
+	children := make([]queryPlan, 0, len(plan.Plans))
+	for _, child := range plan.Plans {
+		if child.ParentRelationship != "InitPlan" && child.ParentRelationship != "SubPlan" {
+			children = append(children, child)
+			continue
+		}
+
+		child.initPlanParams = plan.initPlanParams
+
+		childInputs, childOutputs, err := e.computeNullability(ctx, child, ctes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for input, nullable := range childInputs {
+			inputs[input] = inputs[input] || nullable
+		}
+
+		// CTEs are scoped to the node they are attached to, and may be
+		// read by any CTE defined after them.
+		if cteName, isCTE := strings.CutPrefix(child.SubplanName, "CTE "); isCTE {
+			ctes = maps.Clone(ctes)
+			if ctes == nil {
+				ctes = make(map[string]cteNullability)
 			}
 
-			return inputs, outputs, nil
+			ctes[cteName] = cteNullability{plan: child, outputs: childOutputs}
+			continue
+		}
 
-		default:
-			return e.computeNullability(ctx, plan)
+		for _, name := range subplanReferences(child.SubplanName) {
+			initPlanParams[name] = true
+		}
+	}
+
+	// The parameters of an InitPlan can be read by the node it is
+	// attached to and by anything below it.
+	if len(initPlanParams) > 0 {
+		maps.Copy(initPlanParams, plan.initPlanParams)
+		plan.initPlanParams = initPlanParams
+
+		for idx := range children {
+			children[idx].initPlanParams = initPlanParams
+		}
+	}
+	plan.Plans = children
+
+	nodeInputs, outputs, err := e.computeNodeNullability(ctx, plan, ctes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for input, nullable := range nodeInputs {
+		inputs[input] = inputs[input] || nullable
+	}
+
+	return inputs, outputs, nil
+}
+
+func (e Engine) computeNodeNullability(ctx context.Context, plan queryPlan, ctes map[string]cteNullability) (map[string]bool, map[string]bool, error) {
+	switch plan.NodeType {
+	case "Result":
+		inputs, childOutputs := make(map[string]bool), make(map[string]bool)
+		if len(plan.Plans) > 0 {
+			var err error
+			inputs, childOutputs, err = e.computeNullability(ctx, plan.Plans[0], ctes)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		outputs, err := e.computeOutputNullability(ctx, plan, outputReference(childOutputs))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return inputs, outputs, nil
+
+	case "Hash", "Limit", "Sort", "Materialize", "Unique":
+		return e.computeNullability(ctx, plan.Plans[0], ctes)
+
+	case "Append", "Merge Append", "SetOp", "HashSetOp", "Recursive Union":
+		return e.computeSetNullability(ctx, plan, ctes)
+
+	case "Subquery Scan":
+		inputs, childOutputs, err := e.computeNullability(ctx, plan.Plans[0], ctes)
+		if err != nil {
+			return nil, nil, err
 		}
 
-	case "Seq Scan", "Index Scan", "Index Only Scan":
 		outputs := make(map[string]bool)
+		for idx, output := range plan.Output {
+			outputs[output], err = e.computeSubqueryOutputNullability(ctx, plan, idx, plan.Plans[0], childOutputs)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
 
-		for _, output := range plan.Output {
-			columnName, _ := strings.CutPrefix(output, plan.Alias+".")
+		return inputs, outputs, nil
 
-			nullable, err := e.store.GetColumnNullability(ctx, database.GetColumnNullabilityParams{
-				Schema:     plan.Schema,
-				Relation:   plan.Relation,
-				ColumnName: columnName,
-			})
+	case "CTE Scan":
+		cte, found := ctes[plan.CTEName]
+		if !found {
+			return nil, nil, fmt.Errorf("unknown CTE: %s", plan.CTEName)
+		}
+
+		outputs := make(map[string]bool)
+		for idx, output := range plan.Output {
+			nullable, err := e.computeSubqueryOutputNullability(ctx, plan, idx, cte.plan, cte.outputs)
 			if err != nil {
-				return nil, nil, fmt.Errorf("compute output '%s' nullability: %w", output, err)
+				return nil, nil, err
 			}
 
 			outputs[output] = nullable
@@ -264,74 +776,224 @@ func (e Engine) computeNullability(ctx context.Context, plan queryPlan) (map[str
 
 		return make(map[string]bool), outputs, nil
 
-	case "Hash Join", "Merge Join", "Nested Loop":
+	case "Function Scan":
+		call, isCall := parseFunctionCall(plan.FunctionCall)
+		if !isCall {
+			call = functionCall{Name: plan.FunctionName}
+		}
+
+		nullable, err := e.computeFunctionNullability(ctx, plan.Schema, call, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		outputs := make(map[string]bool)
 		for _, output := range plan.Output {
-			outputs[output] = false
+			outputs[output] = nullable
+		}
+
+		return make(map[string]bool), outputs, nil
+
+	case "ProjectSet":
+		inputs, childOutputs, err := e.computeNullability(ctx, plan.Plans[0], ctes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		outputs, err := e.computeOutputNullability(ctx, plan, outputReference(childOutputs))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return inputs, outputs, nil
+
+	case "Values Scan":
+		// The values themselves are not part of the plan, so we cannot
+		// tell whether any of them are null.
+		outputs := make(map[string]bool)
+		for _, output := range plan.Output {
+			outputs[output] = true
+		}
+
+		return make(map[string]bool), outputs, nil
+
+	case "WorkTable Scan":
+		// The work table of a recursive CTE holds the rows of the CTE
+		// itself. Its nullability is taken from the non-recursive term
+		// by the Recursive Union above it.
+		return make(map[string]bool), make(map[string]bool), nil
+
+	case "Aggregate", "GroupAggregate", "HashAggregate", "Group":
+		inputs, childOutputs, err := e.computeNullability(ctx, plan.Plans[0], ctes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Every group has at least one row in it, so only a plain
+		// aggregate can end up aggregating over an empty set.
+		grouped := plan.NodeType != "Aggregate" || plan.Strategy != "Plain"
+
+		// Grouping sets, such as a rollup, produce rows where the keys of
+		// the other sets are null. They can also hold an empty grouping
+		// set, which aggregates over every row even when there are none.
+		keyOutputs := childOutputs
+		if len(plan.GroupingSets) > 0 {
+			grouped = false
+
+			keyOutputs = make(map[string]bool, len(childOutputs))
+			for output := range childOutputs {
+				keyOutputs[output] = true
+			}
+		}
+
+		outputs, err := e.computeOutputNullability(ctx, plan, e.aggregateReference(keyOutputs, func(call functionCall) bool {
+			return aggregateNullability(call, childOutputs, grouped)
+		}))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return inputs, outputs, nil
+
+	case "WindowAgg":
+		inputs, childOutputs, err := e.computeNullability(ctx, plan.Plans[0], ctes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		outputs, err := e.computeOutputNullability(ctx, plan, e.aggregateReference(childOutputs, windowNullability))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return inputs, outputs, nil
+
+	case "ModifyTable":
+		inputs, _, err := e.computeNullability(ctx, plan.Plans[0], ctes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch plan.Operation {
+		case "Insert":
+			// The child plan produces a value for every column of the
+			// relation in order, so any parameter passed straight through
+			// takes on the nullability of its column. The values of a
+			// multi-row VALUES list are not part of the plan, and are
+			// handled by computeAssignmentNullability.
+			if plan.Plans[0].NodeType != "Values Scan" {
+				nullability, err := e.store.GetRelationNullability(ctx, database.GetRelationNullabilityParams{
+					Schema:   plan.Schema,
+					Relation: plan.Relation,
+				})
+				if err != nil {
+					return nil, nil, fmt.Errorf("get relation nullability: %w", err)
+				}
+
+				for idx, output := range plan.Plans[0].Output[:min(len(nullability), len(plan.Plans[0].Output))] {
+					if name, isParameter := parameterName(output); isParameter && nullability[idx] {
+						inputs[name] = true
+					}
+				}
+			}
+
+		case "Update", "Delete", "Merge":
+			// The child plan finds the rows to modify. Any inputs it
+			// uses come from the WHERE clause, the SET list or the MERGE
+			// source, and its outputs never reach the caller. Parameters
+			// assigned in a SET list are handled by
+			// computeAssignmentNullability.
+
+		default:
+			return nil, nil, fmt.Errorf("unsupported modify operation: %s", plan.Operation)
+		}
+
+		// Anything returned comes from the target relation, so it has
+		// the nullability of the relation's columns.
+		outputs, err := e.computeOutputNullability(ctx, plan, e.relationReference(plan))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return inputs, outputs, nil
+
+	case "Seq Scan", "Index Scan", "Index Only Scan":
+		outputs, err := e.computeOutputNullability(ctx, plan, e.relationReference(plan))
+		if err != nil {
+			return nil, nil, err
 		}
 
-		lhsInputs, lhsOutputs, err := e.computeNullability(ctx, plan.Plans[0])
+		return make(map[string]bool), outputs, nil
+
+	case "Hash Join", "Merge Join", "Nested Loop":
+		lhsInputs, lhsOutputs, err := e.computeNullability(ctx, plan.Plans[0], ctes)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		rhsInputs, rhsOutputs, err := e.computeNullability(ctx, plan.Plans[1])
+		rhsInputs, rhsOutputs, err := e.computeNullability(ctx, plan.Plans[1], ctes)
 		if err != nil {
 			return nil, nil, err
 		}
 
+		columns := make(map[string]bool)
+
 		switch plan.JoinType {
 		case "Left":
 			// When performing a Left join, all columns in the right side
 			// will become nullable
 			for rhsOutput := range rhsOutputs {
-				if _, found := outputs[rhsOutput]; found {
-					outputs[rhsOutput] = true
-				}
+				columns[rhsOutput] = true
 			}
 
 			// We want to keep the nullability of the left side.
-			for lhsOutput, nullable := range lhsOutputs {
-				if _, found := outputs[lhsOutput]; found {
-					outputs[lhsOutput] = nullable
-				}
-			}
+			maps.Copy(columns, lhsOutputs)
 
 		case "Inner":
-			// We want to keep the nullability of the right side.
-			for lhsOutput, nullable := range lhsOutputs {
-				if _, found := outputs[lhsOutput]; found {
-					outputs[lhsOutput] = nullable
-				}
-			}
-
-			// We want to keep the nullability of the left side.
-			for rhsOutput, nullable := range rhsOutputs {
-				if _, found := outputs[rhsOutput]; found {
-					outputs[rhsOutput] = nullable
-				}
-			}
+			// We want to keep the nullability of both sides.
+			maps.Copy(columns, rhsOutputs)
+			maps.Copy(columns, lhsOutputs)
 
 		case "Right":
 			// When performing a Right join, all columns in the left side
 			// will become nullable
 			for lhsOutput := range lhsOutputs {
-				if _, found := outputs[lhsOutput]; found {
-					outputs[lhsOutput] = true
-				}
+				columns[lhsOutput] = true
 			}
 
 			// We want to keep the nullability of the right side.
-			for rhsOutput, nullable := range rhsOutputs {
-				if _, found := outputs[rhsOutput]; found {
-					outputs[rhsOutput] = nullable
-				}
+			maps.Copy(columns, rhsOutputs)
+
+		case "Full":
+			// When performing a Full join, either side may be missing
+			// so all columns become nullable.
+			for lhsOutput := range lhsOutputs {
+				columns[lhsOutput] = true
 			}
 
+			for rhsOutput := range rhsOutputs {
+				columns[rhsOutput] = true
+			}
+
+		case "Semi", "Anti":
+			// Semi and Anti joins only emit rows from the left side, the
+			// right side is only used to filter them.
+			maps.Copy(columns, lhsOutputs)
+
+		case "Right Semi", "Right Anti":
+			// The planner may swap the sides of a Semi or Anti join, in
+			// which case the rows are emitted from the right side.
+			maps.Copy(columns, rhsOutputs)
+
 		default:
 			return nil, nil, fmt.Errorf("unsupported join type: %s", plan.JoinType)
 		}
 
+		outputs, err := e.computeOutputNullability(ctx, plan, outputReference(columns))
+		if err != nil {
+			return nil, nil, err
+		}
+
 		inputs := rhsInputs
 		for k, v := range lhsInputs {
 			inputs[k] = v
@@ -343,3 +1005,506 @@ func (e Engine) computeNullability(ctx context.Context, plan queryPlan) (map[str
 		return nil, nil, fmt.Errorf("unsupported node type: %s", plan.NodeType)
 	}
 }
+
+// computeAssignmentNullability computes the nullability of the parameters
+// assigned to a column in the SET list of an UPDATE or an ON CONFLICT DO
+// UPDATE, or in the rows of a multi-row INSERT. The plan does not say
+// which column a value is assigned to, so the SET and VALUES lists are
+// read from the query itself and matched up with the relations being
+// modified.
+func (e Engine) computeAssignmentNullability(ctx context.Context, query string, plan queryPlan) (map[string]bool, error) {
+	inputs := make(map[string]bool)
+
+	if err := e.computeInsertedValuesNullability(ctx, query, plan, inputs); err != nil {
+		return nil, err
+	}
+
+	relations := updatedRelations(plan)
+	if len(relations) == 0 {
+		return inputs, nil
+	}
+
+	for _, assignment := range parseAssignments(query) {
+		name, isParameter := parameterName(assignment.Value)
+		if !isParameter {
+			continue
+		}
+
+		for _, relation := range relations {
+			nullable, err := e.store.GetColumnNullability(ctx, database.GetColumnNullabilityParams{
+				Schema:     relation.Schema,
+				Relation:   relation.Relation,
+				ColumnName: assignment.Column,
+			})
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("get column '%s' nullability: %w", assignment.Column, err)
+			}
+
+			inputs[name] = inputs[name] || nullable
+		}
+	}
+
+	return inputs, nil
+}
+
+// computeInsertedValuesNullability computes the nullability of the
+// parameters in the rows of an INSERT with a multi-row VALUES list. The
+// Values Scan below the INSERT outputs the columns of the VALUES list in
+// the order of the relation's columns, and the parameters found at that
+// position of each row take on the nullability of the column.
+func (e Engine) computeInsertedValuesNullability(ctx context.Context, query string, plan queryPlan, inputs map[string]bool) error {
+	relations := insertedValuesRelations(plan)
+	if len(relations) == 0 {
+		return nil
+	}
+
+	inserts := parseInsertValues(query)
+
+	for _, relation := range relations {
+		// Inserts into the same relation are matched up in order.
+		idx := slices.IndexFunc(inserts, func(insert insertValues) bool {
+			return insert.Relation == relation.Relation
+		})
+		if idx < 0 {
+			continue
+		}
+
+		rows := inserts[idx].Rows
+		inserts = slices.Delete(inserts, idx, idx+1)
+
+		nullability, err := e.store.GetRelationNullability(ctx, database.GetRelationNullabilityParams{
+			Schema:   relation.Schema,
+			Relation: relation.Relation,
+		})
+		if err != nil {
+			return fmt.Errorf("get relation nullability: %w", err)
+		}
+
+		values := relation.Plans[0]
+		for column, output := range values.Output[:min(len(nullability), len(values.Output))] {
+			position, isValue := valuesColumn(output, values.Alias)
+			if !isValue || !nullability[column] {
+				continue
+			}
+
+			for _, row := range rows {
+				if position >= len(row) {
+					continue
+				}
+
+				if name, isParameter := parameterName(row[position]); isParameter {
+					inputs[name] = true
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// insertedValuesRelations returns every ModifyTable node in the plan that
+// inserts the rows of a multi-row VALUES list.
+func insertedValuesRelations(plan queryPlan) []queryPlan {
+	var relations []queryPlan
+
+	if plan.NodeType == "ModifyTable" && plan.Operation == "Insert" && len(plan.Plans) > 0 && plan.Plans[0].NodeType == "Values Scan" {
+		relations = append(relations, plan)
+	}
+
+	for _, child := range plan.Plans {
+		relations = append(relations, insertedValuesRelations(child)...)
+	}
+
+	return relations
+}
+
+// updatedRelations returns every ModifyTable node in the plan that updates
+// its relation, either through an UPDATE, an ON CONFLICT DO UPDATE or a
+// MERGE. The INSERT action of a MERGE has no SET list, so its values are
+// not matched up with their columns.
+func updatedRelations(plan queryPlan) []queryPlan {
+	var relations []queryPlan
+
+	if plan.NodeType == "ModifyTable" && (plan.Operation == "Update" || plan.Operation == "Merge" || plan.ConflictResolution == "UPDATE") {
+		relations = append(relations, plan)
+	}
+
+	for _, child := range plan.Plans {
+		relations = append(relations, updatedRelations(child)...)
+	}
+
+	return relations
+}
+
+// computeSetNullability computes the nullability of a node that combines
+// the rows of its children, such as a UNION. The outputs of the children
+// line up by position, and an output is nullable when it is nullable in
+// any of them.
+func (e Engine) computeSetNullability(ctx context.Context, plan queryPlan, ctes map[string]cteNullability) (map[string]bool, map[string]bool, error) {
+	inputs := make(map[string]bool)
+	nullability := make([]bool, len(plan.Output))
+
+	for _, child := range plan.Plans {
+		childInputs, childOutputs, err := e.computeNullability(ctx, child, ctes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for input, nullable := range childInputs {
+			inputs[input] = inputs[input] || nullable
+		}
+
+		for idx := range min(len(nullability), len(child.Output)) {
+			if childOutputs[child.Output[idx]] {
+				nullability[idx] = true
+			}
+		}
+	}
+
+	outputs := make(map[string]bool)
+	for idx, output := range plan.Output {
+		outputs[output] = outputs[output] || nullability[idx]
+	}
+
+	return inputs, outputs, nil
+}
+
+// computeSubqueryOutputNullability computes the nullability of the
+// output of a subquery or CTE scan. Outputs name a column of the subquery,
+// which is matched against the column names of the subquery's own outputs
+// before falling back to their position. Anything else is an expression
+// over those columns.
+func (e Engine) computeSubqueryOutputNullability(ctx context.Context, plan queryPlan, idx int, child queryPlan, childOutputs map[string]bool) (bool, error) {
+	output := plan.Output[idx]
+	resolve := initPlanReference(plan.initPlanParams, subqueryReference(plan.Alias, child, childOutputs))
+
+	if nullable, found, _ := resolve(ctx, output); found {
+		return nullable, nil
+	}
+
+	if _, isColumn := cutQualifier(output, plan.Alias); isColumn && len(plan.Output) == len(child.Output) {
+		return childOutputs[child.Output[idx]], nil
+	}
+
+	return e.computeExpressionNullability(ctx, output, resolve)
+}
+
+// computeFunctionNullability computes the nullability of a function call's
+// result. Strict functions only return null when given a null argument, so
+// they inherit the nullability of their arguments. Functions returning
+// records may leave any of their columns null, and set-returning functions
+// may return null rows from non-null arguments, such as `unnest` over an
+// array holding nulls.
+func (e Engine) computeFunctionNullability(ctx context.Context, schema string, call functionCall, resolve referenceNullability) (bool, error) {
+	function, err := e.store.GetFunctionByName(ctx, database.GetFunctionByNameParams{
+		Schema: schema,
+		Name:   call.Name,
+	})
+	if err != nil {
+		return false, fmt.Errorf("get function '%s': %w", call.Name, err)
+	}
+
+	if !function.Strict || function.ReturnsRecord || function.ReturnsSet {
+		return true, nil
+	}
+
+	return e.anyExpressionNullable(ctx, call.Args, resolve)
+}
+
+// referenceNullability reports the nullability of an expression that a
+// plan node knows about, such as a column of the node's relation or one
+// of its children's outputs. It reports false when the node does not know
+// the expression.
+type referenceNullability func(ctx context.Context, expr string) (nullable bool, found bool, err error)
+
+// outputReference resolves references to the outputs of a node's children.
+func outputReference(outputs map[string]bool) referenceNullability {
+	return func(ctx context.Context, expr string) (bool, bool, error) {
+		nullable, found := outputs[expr]
+		return nullable, found, nil
+	}
+}
+
+// subqueryReference resolves references to the columns of a subquery or
+// CTE by matching them against the column names of the subquery's outputs.
+func subqueryReference(alias string, child queryPlan, childOutputs map[string]bool) referenceNullability {
+	return func(ctx context.Context, expr string) (bool, bool, error) {
+		columnName, isColumn := cutQualifier(expr, alias)
+		if !isColumn {
+			return false, false, nil
+		}
+
+		for _, childOutput := range child.Output {
+			if outputColumnName(childOutput) == columnName {
+				return childOutputs[childOutput], true, nil
+			}
+		}
+
+		return false, false, nil
+	}
+}
+
+// initPlanReference resolves references to the parameters of InitPlans,
+// which are null when the InitPlan returns no rows, before anything else.
+func initPlanReference(params map[string]bool, resolve referenceNullability) referenceNullability {
+	if len(params) == 0 {
+		return resolve
+	}
+
+	return func(ctx context.Context, expr string) (bool, bool, error) {
+		if params[expr] {
+			return true, true, nil
+		}
+
+		if resolve == nil {
+			return false, false, nil
+		}
+
+		return resolve(ctx, expr)
+	}
+}
+
+// relationReference resolves references to the columns of the plan's
+// relation. System columns are not known to the catalog, and so are not
+// resolved.
+func (e Engine) relationReference(plan queryPlan) referenceNullability {
+	return func(ctx context.Context, expr string) (bool, bool, error) {
+		columnName, isColumn := cutQualifier(expr, plan.Alias)
+		if !isColumn {
+			return false, false, nil
+		}
+
+		// A whole-row reference to a relation is never null.
+		if columnName == "*" {
+			return false, true, nil
+		}
+
+		columnName, isColumn = unquoteIdentifier(columnName)
+		if !isColumn {
+			return false, false, nil
+		}
+
+		nullable, err := e.store.GetColumnNullability(ctx, database.GetColumnNullabilityParams{
+			Schema:     plan.Schema,
+			Relation:   plan.Relation,
+			ColumnName: columnName,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, false, nil
+		} else if err != nil {
+			return false, false, fmt.Errorf("get column '%s' nullability: %w", columnName, err)
+		}
+
+		return nullable, true, nil
+	}
+}
+
+// aggregateReference resolves references to the outputs of a node's child
+// and to the aggregate or window function calls the node computes.
+func (e Engine) aggregateReference(childOutputs map[string]bool, callNullability func(call functionCall) bool) referenceNullability {
+	return func(ctx context.Context, expr string) (bool, bool, error) {
+		if nullable, found := childOutputs[expr]; found {
+			return nullable, true, nil
+		}
+
+		call, isCall := parseFunctionCall(expr)
+		if !isCall {
+			return false, false, nil
+		}
+
+		function, err := e.store.GetFunctionByName(ctx, database.GetFunctionByNameParams{
+			Name: call.Name,
+		})
+		if err != nil {
+			return false, false, fmt.Errorf("get function '%s': %w", call.Name, err)
+		}
+
+		if !function.Aggregate {
+			return false, false, nil
+		}
+
+		return callNullability(call), true, nil
+	}
+}
+
+// computeOutputNullability computes the nullability of every output of the
+// plan, treating them as expressions over the references the node knows
+// about.
+func (e Engine) computeOutputNullability(ctx context.Context, plan queryPlan, resolve referenceNullability) (map[string]bool, error) {
+	resolve = initPlanReference(plan.initPlanParams, resolve)
+	outputs := make(map[string]bool)
+
+	for _, output := range plan.Output {
+		nullable, err := e.computeExpressionNullability(ctx, output, resolve)
+		if err != nil {
+			return nil, fmt.Errorf("compute output '%s' nullability: %w", output, err)
+		}
+
+		outputs[output] = nullable
+	}
+
+	return outputs, nil
+}
+
+// computeExpressionNullability computes the nullability of an expression
+// as it is written in the EXPLAIN output. References the node knows about
+// keep their nullability, constants are never null, and anything built on
+// top of them is null when its inputs allow it.
+func (e Engine) computeExpressionNullability(ctx context.Context, expr string, resolve referenceNullability) (bool, error) {
+	expr = strings.TrimSpace(expr)
+
+	if resolve != nil {
+		nullable, found, err := resolve(ctx, expr)
+		if err != nil {
+			return false, err
+		} else if found {
+			return nullable, nil
+		}
+	}
+
+	if inner := stripParens(expr); inner != expr {
+		return e.computeExpressionNullability(ctx, inner, resolve)
+	}
+
+	// A scalar subquery is null when it returns no rows. Any other
+	// subquery, such as `x IN (...)`, is null when it meets a null.
+	if isSubplanReference(expr) {
+		return true, nil
+	}
+
+	if results, hasElse, isCase := caseResults(expr); isCase {
+		// Without an ELSE, a CASE is null when none of its branches match.
+		if !hasElse {
+			return true, nil
+		}
+
+		return e.anyExpressionNullable(ctx, results, resolve)
+	}
+
+	if operand, isCast := cutCast(expr); isCast {
+		return e.computeExpressionNullability(ctx, operand, resolve)
+	}
+
+	if expr == "NULL" {
+		return true, nil
+	} else if isLiteral(expr) {
+		return false, nil
+	}
+
+	if call, isCall := parseFunctionCall(expr); isCall && call.Suffix == "" {
+		switch call.Name {
+		case "COALESCE", "GREATEST", "LEAST":
+			// These skip over null arguments, so they are only null when
+			// every one of their arguments is.
+			for _, arg := range call.Args {
+				nullable, err := e.computeExpressionNullability(ctx, arg, resolve)
+				if err != nil {
+					return false, err
+				} else if !nullable {
+					return false, nil
+				}
+			}
+
+			return true, nil
+
+		case "NULLIF":
+			return true, nil
+
+		case "EXISTS":
+			return false, nil
+
+		case "ROW", "ARRAY":
+			return false, nil
+
+		default:
+			return e.computeFunctionNullability(ctx, "", call, resolve)
+		}
+	}
+
+	if tokens := splitTokens(expr); len(tokens) > 1 {
+		// Tests such as IS NULL or IS DISTINCT FROM always produce a
+		// value.
+		if slices.Contains(tokens, "IS") {
+			return false, nil
+		}
+
+		// Operators are almost always strict, so the result is null when
+		// any of the operands are.
+		operands := slices.DeleteFunc(tokens, isOperator)
+		return e.anyExpressionNullable(ctx, operands, resolve)
+	}
+
+	// Anything left is a reference the node does not know about, such as
+	// a parameter or a system column.
+	return false, nil
+}
+
+// anyExpressionNullable reports whether any of the expressions are nullable.
+func (e Engine) anyExpressionNullable(ctx context.Context, exprs []string, resolve referenceNullability) (bool, error) {
+	for _, expr := range exprs {
+		nullable, err := e.computeExpressionNullability(ctx, expr, resolve)
+		if err != nil {
+			return false, err
+		} else if nullable {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// nonNullAggregates produce a value for any group, as they keep the nulls
+// they are given rather than skipping over them.
+var nonNullAggregates = []string{
+	"array_agg", "json_agg", "jsonb_agg", "json_object_agg", "jsonb_object_agg",
+}
+
+// strictAggregates skip over nulls, so they produce a value for any group
+// where their first argument is not null.
+var strictAggregates = []string{
+	"min", "max", "sum", "avg", "any_value", "bool_and", "bool_or", "every",
+	"bit_and", "bit_or", "bit_xor", "string_agg", "xmlagg", "range_agg",
+	"range_intersect_agg",
+}
+
+// aggregateNullability computes the nullability of an aggregate call.
+// Aggregates other than count are null when they aggregate over nothing,
+// and any aggregate not known to produce a value for a group, such as
+// stddev_samp over a single row or a user-defined aggregate, may be null.
+func aggregateNullability(call functionCall, childOutputs map[string]bool, grouped bool) bool {
+	if call.Name == "count" {
+		return false
+	}
+
+	// A filter can remove every row from a group.
+	if !grouped || call.Suffix != "" || len(call.Args) == 0 {
+		return true
+	}
+
+	switch {
+	case slices.Contains(nonNullAggregates, call.Name):
+		return false
+
+	case slices.Contains(strictAggregates, call.Name):
+		nullable, found := childOutputs[call.Args[0]]
+		return !found || nullable
+
+	default:
+		return true
+	}
+}
+
+// windowNullability computes the nullability of a window function call.
+// Counting and ranking functions always produce a value, while anything
+// else may be looking at an empty frame or a missing row.
+func windowNullability(call functionCall) bool {
+	switch call.Name {
+	case "count", "row_number", "rank", "dense_rank", "percent_rank", "cume_dist", "ntile":
+		return false
+
+	default:
+		return true
+	}
+}
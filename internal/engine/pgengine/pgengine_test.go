@@ -18,6 +18,92 @@ import (
 func TestQueries(t *testing.T) {
 	t.Parallel()
 
+	addressType := engine.Type{
+		Kind: engine.TypeKindComposite,
+		Name: "address",
+		Attributes: []engine.Attribute{
+			{
+				Name: "street",
+				Type: engine.Type{
+					Kind:     engine.TypeKindBase,
+					Name:     "text",
+					Nullable: true,
+				},
+			},
+			{
+				Name: "number",
+				Type: engine.Type{
+					Kind:     engine.TypeKindBase,
+					Name:     "int4",
+					Nullable: true,
+				},
+			},
+		},
+	}
+
+	emailType := engine.Type{
+		Kind: engine.TypeKindDomain,
+		Name: "email",
+		Base: &engine.Type{
+			Kind: engine.TypeKindBase,
+			Name: "text",
+		},
+	}
+
+	positiveIntType := engine.Type{
+		Kind: engine.TypeKindDomain,
+		Name: "positive_int",
+		Base: &engine.Type{
+			Kind: engine.TypeKindBase,
+			Name: "int4",
+		},
+	}
+
+	usersType := engine.Type{
+		Kind: engine.TypeKindComposite,
+		Name: "users",
+		Attributes: []engine.Attribute{
+			{
+				Name: "id",
+				Type: positiveIntType,
+			},
+			{
+				Name: "email",
+				Type: emailType,
+			},
+			{
+				Name: "backup_email",
+				Type: nullable(emailType),
+			},
+		},
+	}
+
+	moodType := engine.Type{
+		Kind:     engine.TypeKindEnum,
+		Name:     "mood",
+		Variants: []string{"happy", "sad"},
+	}
+
+	tstzrangeType := engine.Type{
+		Kind: engine.TypeKindRange,
+		Name: "tstzrange",
+		Elem: &engine.Type{
+			Kind: engine.TypeKindBase,
+			Name: "timestamptz",
+		},
+	}
+
+	amountType := engine.Type{
+		Kind: engine.TypeKindDomain,
+		Name: "amount",
+		Base: &engine.Type{
+			Kind:      engine.TypeKindBase,
+			Name:      "numeric",
+			Precision: 12,
+			Scale:     2,
+		},
+	}
+
 	tests := []struct {
 		name            string
 		schema          string
@@ -66,6 +152,7 @@ func TestQueries(t *testing.T) {
 								Kind: engine.TypeKindBase,
 								Name: "int4",
 							},
+							Column: "users.id",
 						},
 						{
 							Name: "username",
@@ -74,6 +161,7 @@ func TestQueries(t *testing.T) {
 								Name:     "text",
 								Nullable: true,
 							},
+							Column: "users.username",
 						},
 					},
 				},
@@ -95,6 +183,7 @@ func TestQueries(t *testing.T) {
 								Kind: engine.TypeKindBase,
 								Name: "int4",
 							},
+							Column: "users.id",
 						},
 						{
 							Name: "username",
@@ -103,6 +192,7 @@ func TestQueries(t *testing.T) {
 								Name:     "text",
 								Nullable: true,
 							},
+							Column: "users.username",
 						},
 					},
 				},
@@ -191,6 +281,7 @@ func TestQueries(t *testing.T) {
 								Kind: engine.TypeKindBase,
 								Name: "int4",
 							},
+							Column: "employees.id",
 						},
 						{
 							Name: "employee_name",
@@ -198,6 +289,7 @@ func TestQueries(t *testing.T) {
 								Kind: engine.TypeKindBase,
 								Name: "text",
 							},
+							Column: "employees.name",
 						},
 						{
 							Name: "department_id",
@@ -206,6 +298,7 @@ func TestQueries(t *testing.T) {
 								Name:     "int4",
 								Nullable: true,
 							},
+							Column: "departments.id",
 						},
 						{
 							Name: "department_name",
@@ -214,6 +307,7 @@ func TestQueries(t *testing.T) {
 								Name:     "text",
 								Nullable: true,
 							},
+							Column: "departments.name",
 						},
 					},
 				},
@@ -228,6 +322,7 @@ func TestQueries(t *testing.T) {
 								Name:     "int4",
 								Nullable: true,
 							},
+							Column: "employees.id",
 						},
 						{
 							Name: "employee_name",
@@ -236,6 +331,7 @@ func TestQueries(t *testing.T) {
 								Name:     "text",
 								Nullable: true,
 							},
+							Column: "employees.name",
 						},
 						{
 							Name: "department_id",
@@ -243,6 +339,7 @@ func TestQueries(t *testing.T) {
 								Kind: engine.TypeKindBase,
 								Name: "int4",
 							},
+							Column: "departments.id",
 						},
 						{
 							Name: "department_name",
@@ -250,6 +347,7 @@ func TestQueries(t *testing.T) {
 								Kind: engine.TypeKindBase,
 								Name: "text",
 							},
+							Column: "departments.name",
 						},
 					},
 				},
@@ -263,6 +361,7 @@ func TestQueries(t *testing.T) {
 								Kind: engine.TypeKindBase,
 								Name: "int4",
 							},
+							Column: "employees.id",
 						},
 						{
 							Name: "employee_name",
@@ -270,6 +369,7 @@ func TestQueries(t *testing.T) {
 								Kind: engine.TypeKindBase,
 								Name: "text",
 							},
+							Column: "employees.name",
 						},
 						{
 							Name: "department_id",
@@ -277,6 +377,7 @@ func TestQueries(t *testing.T) {
 								Kind: engine.TypeKindBase,
 								Name: "int4",
 							},
+							Column: "departments.id",
 						},
 						{
 							Name: "department_name",
@@ -284,17 +385,222 @@ func TestQueries(t *testing.T) {
 								Kind: engine.TypeKindBase,
 								Name: "text",
 							},
+							Column: "departments.name",
 						},
 					},
 				},
 			},
 		},
 		{
-			name: "NoSchema",
+			name: "CompositeTypes",
+			schema: `
+				create type address as ( street text, number int );
+				create table users ( id int not null, home address );
+			`,
 			queries: map[string]string{
-				"GetOne": `
+				"GetUserHomes": `
+					-- :many
+					select id, home from users
+				`,
+				"GetUserRows": `
+					-- :many
+					select u from users u
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "text",
+				},
+				addressType,
+				{
+					Kind: engine.TypeKindComposite,
+					Name: "users",
+					Attributes: []engine.Attribute{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+						{
+							Name: "home",
+							Type: nullable(addressType),
+						},
+					},
+				},
+			},
+			expectedQueries: map[string]engine.Query{
+				"GetUserHomes": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "users.id",
+						},
+						{
+							Name:   "home",
+							Type:   nullable(addressType),
+							Column: "users.home",
+						},
+					},
+				},
+				"GetUserRows": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "u",
+							Type: engine.Type{
+								Kind: engine.TypeKindComposite,
+								Name: "users",
+								Attributes: []engine.Attribute{
+									{
+										Name: "id",
+										Type: engine.Type{
+											Kind: engine.TypeKindBase,
+											Name: "int4",
+										},
+									},
+									{
+										Name: "home",
+										Type: nullable(addressType),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "DomainTypes",
+			schema: `
+				create domain email as text check ( value like '%@%' );
+				create domain positive_int as int not null check ( value > 0 );
+				create table users ( id positive_int, email email not null, backup_email email );
+			`,
+			queries: map[string]string{
+				"GetUsers": `
+					-- :many
+					select * from users
+				`,
+				"GetUserRows": `
+					-- :many
+					select u from users u
+				`,
+				"InsertUser": `
+					-- :exec
+					-- $1: id
+					-- $2: email
+					-- $3: backup_email
+					insert into users ( id, email, backup_email ) values ($1, $2, $3)
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "text",
+				},
+				emailType,
+				positiveIntType,
+				usersType,
+			},
+			expectedQueries: map[string]engine.Query{
+				"GetUsers": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name:   "id",
+							Type:   positiveIntType,
+							Column: "users.id",
+						},
+						{
+							Name:   "email",
+							Type:   emailType,
+							Column: "users.email",
+						},
+						{
+							Name:   "backup_email",
+							Type:   nullable(emailType),
+							Column: "users.backup_email",
+						},
+					},
+				},
+				"GetUserRows": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "u",
+							Type: usersType,
+						},
+					},
+				},
+				"InsertUser": {
+					Type: engine.QueryTypeExec,
+					Inputs: []engine.Input{
+						{
+							Name: "id",
+							Type: positiveIntType,
+						},
+						{
+							Name: "email",
+							Type: emailType,
+						},
+						{
+							Name: "backup_email",
+							Type: nullable(emailType),
+						},
+					},
+					Outputs: []engine.Output{},
+				},
+			},
+		},
+		{
+			name: "ArrayTypes",
+			schema: `
+				create type mood as enum ( 'happy', 'sad' );
+				create table posts ( id int not null, tags text[] not null, moods mood[] );
+				create table comments ( post_id int not null, body text );
+			`,
+			queries: map[string]string{
+				"GetPosts": `
+					-- :many
+					select * from posts
+				`,
+				"GetPostsByIDs": `
+					-- :many
+					-- $1: ids
+					select id from posts where id = any($1)
+				`,
+				"GetPostsByTags": `
+					-- :many
+					-- $1[!]: tags
+					select id from posts where tags && $1
+				`,
+				"GetPostIDs": `
 					-- :one
-					select 1
+					select array_agg(id) as "ids[!]" from posts
+				`,
+				"GetCommentBodies": `
+					-- :many
+					select post_id, array_agg(body) as bodies from comments group by post_id
 				`,
 			},
 			expectedTypes: []engine.Type{
@@ -302,49 +608,2651 @@ func TestQueries(t *testing.T) {
 					Kind: engine.TypeKindBase,
 					Name: "int4",
 				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "text",
+				},
+				moodType,
 			},
 			expectedQueries: map[string]engine.Query{
-				"GetOne": {
+				"GetPosts": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "posts.id",
+						},
+						{
+							Name: "tags",
+							Type: engine.Type{
+								Kind: engine.TypeKindArray,
+								Name: "_text",
+								Elem: &engine.Type{
+									Kind:     engine.TypeKindBase,
+									Name:     "text",
+									Nullable: true,
+								},
+							},
+							Column: "posts.tags",
+						},
+						{
+							Name: "moods",
+							Type: engine.Type{
+								Kind: engine.TypeKindArray,
+								Name: "_mood",
+								Elem: &engine.Type{
+									Kind:     engine.TypeKindEnum,
+									Name:     "mood",
+									Variants: []string{"happy", "sad"},
+									Nullable: true,
+								},
+								Nullable: true,
+							},
+							Column: "posts.moods",
+						},
+					},
+				},
+				"GetPostsByIDs": {
+					Type: engine.QueryTypeMany,
+					Inputs: []engine.Input{
+						{
+							Name: "ids",
+							Type: engine.Type{
+								Kind: engine.TypeKindArray,
+								Name: "_int4",
+								Elem: &engine.Type{
+									Kind:     engine.TypeKindBase,
+									Name:     "int4",
+									Nullable: true,
+								},
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "posts.id",
+						},
+					},
+				},
+				"GetPostsByTags": {
+					Type: engine.QueryTypeMany,
+					Inputs: []engine.Input{
+						{
+							Name: "tags",
+							Type: engine.Type{
+								Kind: engine.TypeKindArray,
+								Name: "_text",
+								Elem: &engine.Type{
+									Kind: engine.TypeKindBase,
+									Name: "text",
+								},
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "posts.id",
+						},
+					},
+				},
+				"GetPostIDs": {
 					Type:   engine.QueryTypeOne,
 					Inputs: []engine.Input{},
 					Outputs: []engine.Output{
 						{
+							Name: "ids",
 							Type: engine.Type{
-								Kind:     engine.TypeKindBase,
-								Name:     "int4",
-								Nullable: false,
+								Kind: engine.TypeKindArray,
+								Name: "_int4",
+								Elem: &engine.Type{
+									Kind: engine.TypeKindBase,
+									Name: "int4",
+								},
+								Nullable: true,
+							},
+						},
+					},
+				},
+				"GetCommentBodies": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "post_id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "comments.post_id",
+						},
+						{
+							Name: "bodies",
+							Type: engine.Type{
+								Kind: engine.TypeKindArray,
+								Name: "_text",
+								Elem: &engine.Type{
+									Kind:     engine.TypeKindBase,
+									Name:     "text",
+									Nullable: true,
+								},
 							},
 						},
 					},
 				},
 			},
 		},
-	}
-
-	for _, tt := range tests {
-		// Rather than hand-write the expected SQL, we're going to generate
-		// it here.
-		for queryName, query := range tt.queries {
-			expectedQuery := tt.expectedQueries[queryName]
-			expectedQuery.Name = queryName
-			expectedQuery.SQL = strings.TrimSpace(query)
-			tt.expectedQueries[queryName] = expectedQuery
-		}
-
-		t.Run(tt.name, func(t *testing.T) {
-			t.Parallel()
-
-			db := mustCreateDB(t, tt.schema)
-			e := pgengine.New(db)
-
-			resolved, err := e.ResolveQueries(t.Context(), tt.queries)
-			require.Nil(t, err)
-
-			assert.ElementsMatch(t, resolved.Types, tt.expectedTypes)
-			assert.Equal(t, resolved.Queries, tt.expectedQueries)
-		})
-	}
+		{
+			name: "RangeTypes",
+			schema: `
+				create table bookings ( id int not null, during tstzrange not null, slots int8multirange );
+			`,
+			queries: map[string]string{
+				"GetBookings": `
+					-- :many
+					select * from bookings
+				`,
+				"GetBookingsDuring": `
+					-- :many
+					-- $1: during
+					select id from bookings where during && $1
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int8",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "timestamptz",
+				},
+			},
+			expectedQueries: map[string]engine.Query{
+				"GetBookings": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "bookings.id",
+						},
+						{
+							Name:   "during",
+							Type:   tstzrangeType,
+							Column: "bookings.during",
+						},
+						{
+							Name: "slots",
+							Type: engine.Type{
+								Kind: engine.TypeKindMultirange,
+								Name: "int8multirange",
+								Elem: &engine.Type{
+									Kind: engine.TypeKindBase,
+									Name: "int8",
+								},
+								Nullable: true,
+							},
+							Column: "bookings.slots",
+						},
+					},
+				},
+				"GetBookingsDuring": {
+					Type: engine.QueryTypeMany,
+					Inputs: []engine.Input{
+						{
+							Name: "during",
+							Type: tstzrangeType,
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "bookings.id",
+						},
+					},
+				},
+			},
+		},
+		{
+			name:   "ModifyTableOperations",
+			schema: `create table users ( id int not null, name text, email text not null );`,
+			queries: map[string]string{
+				"UpdateUserName": `
+					-- :one
+					-- $1: name
+					-- $2: id
+					update users set name = $1 where id = $2 returning id, name
+				`,
+				"DeleteUser": `
+					-- :exec
+					-- $1: id
+					delete from users where id = $1
+				`,
+				"DeleteUserReturning": `
+					-- :many
+					-- $1: id
+					delete from users where id = $1 returning name, email
+				`,
+				"MergeUser": `
+					-- :exec
+					-- $1: id
+					-- $2: email
+					merge into users u
+					using ( select $1::int as id, $2::text as email ) s
+					on u.id = s.id
+					when matched then update set email = s.email
+					when not matched then insert ( id, email ) values ( s.id, s.email )
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "text",
+				},
+			},
+			expectedQueries: map[string]engine.Query{
+				"UpdateUserName": {
+					Type: engine.QueryTypeOne,
+					Inputs: []engine.Input{
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+						},
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "users.id",
+						},
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+							Column: "users.name",
+						},
+					},
+				},
+				"DeleteUser": {
+					Type: engine.QueryTypeExec,
+					Inputs: []engine.Input{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+					Outputs: []engine.Output{},
+				},
+				"DeleteUserReturning": {
+					Type: engine.QueryTypeMany,
+					Inputs: []engine.Input{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+							Column: "users.name",
+						},
+						{
+							Name: "email",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+							Column: "users.email",
+						},
+					},
+				},
+				"MergeUser": {
+					Type: engine.QueryTypeExec,
+					Inputs: []engine.Input{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+						{
+							Name: "email",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+						},
+					},
+					Outputs: []engine.Output{},
+				},
+			},
+		},
+		{
+			name: "Aggregates",
+			schema: `
+				create table employees ( id int not null, name text not null, department_id int, salary int not null );
+				create aggregate my_sum(int) ( sfunc = int4pl, stype = int );
+			`,
+			queries: map[string]string{
+				"CountEmployees": `
+					-- :one
+					select count(*) from employees
+				`,
+				"GetSalaryStats": `
+					-- :one
+					select sum(salary), min(name), max(salary), avg(salary) from employees
+				`,
+				"GetDepartmentTotals": `
+					-- :many
+					select department_id, count(*) as employees, sum(salary) as total
+					from employees
+					group by department_id
+				`,
+				"GetDepartmentStats": `
+					-- :many
+					select department_id, stddev_samp(salary) as deviation, my_sum(salary) as total
+					from employees
+					group by department_id
+				`,
+				"GetSalaryRollup": `
+					-- :many
+					select id, sum(salary) as total from employees group by rollup(id)
+				`,
+				"GetEmployeeRanks": `
+					-- :many
+					select
+						id,
+						row_number() over (order by salary) as position,
+						lag(name) over (order by salary) as previous_name
+					from employees
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int8",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "numeric",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "text",
+				},
+			},
+			expectedQueries: map[string]engine.Query{
+				"CountEmployees": {
+					Type:   engine.QueryTypeOne,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "count",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int8",
+							},
+						},
+					},
+				},
+				"GetSalaryStats": {
+					Type:   engine.QueryTypeOne,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "sum",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int8",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "min",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "max",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int4",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "avg",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "numeric",
+								Nullable: true,
+							},
+						},
+					},
+				},
+				"GetDepartmentTotals": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "department_id",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int4",
+								Nullable: true,
+							},
+							Column: "employees.department_id",
+						},
+						{
+							Name: "employees",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int8",
+							},
+						},
+						{
+							Name: "total",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int8",
+							},
+						},
+					},
+				},
+				"GetDepartmentStats": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "department_id",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int4",
+								Nullable: true,
+							},
+							Column: "employees.department_id",
+						},
+						{
+							Name: "deviation",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "numeric",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "total",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int4",
+								Nullable: true,
+							},
+						},
+					},
+				},
+				"GetSalaryRollup": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int4",
+								Nullable: true,
+							},
+							Column: "employees.id",
+						},
+						{
+							Name: "total",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int8",
+								Nullable: true,
+							},
+						},
+					},
+				},
+				"GetEmployeeRanks": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "employees.id",
+						},
+						{
+							Name: "position",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int8",
+							},
+						},
+						{
+							Name: "previous_name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SetOperations",
+			schema: `
+				create table customers ( id int not null, name text, email text not null );
+				create table suppliers ( id int not null, name text not null, email text );
+			`,
+			queries: map[string]string{
+				"GetContactsAll": `
+					-- :many
+					select name as contact, email from customers
+					union all
+					select name, email from suppliers
+				`,
+				"GetContacts": `
+					-- :many
+					select name, email from customers
+					union
+					select name, email from suppliers
+				`,
+				"GetSharedNames": `
+					-- :many
+					select name from customers
+					intersect
+					select name from suppliers
+				`,
+				"GetCustomerOnlyIDs": `
+					-- :many
+					select id from customers
+					except
+					select id from suppliers
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "text",
+				},
+			},
+			expectedQueries: map[string]engine.Query{
+				"GetContactsAll": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "contact",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "email",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+					},
+				},
+				"GetContacts": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "email",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+					},
+				},
+				"GetSharedNames": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+					},
+				},
+				"GetCustomerOnlyIDs": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "CommonTableExpressions",
+			schema: `
+				create table users ( id int not null, name text, manager_id int );
+				create table orders ( id int not null, user_id int not null, total int not null );
+			`,
+			queries: map[string]string{
+				"GetManagedUsers": `
+					-- :many
+					with managed as materialized (
+						select id, name from users where manager_id is not null
+					)
+					select id, name from managed
+				`,
+				"GetReportingChain": `
+					-- :many
+					-- $1: id
+					with recursive chain as (
+						select id, manager_id from users where id = $1
+						union all
+						select u.id, u.manager_id from users u join chain c on u.manager_id = c.id
+					)
+					select id, manager_id from chain
+				`,
+				"DeleteUnmanagedUsers": `
+					-- :many
+					with deleted as (
+						delete from users where manager_id is null returning id, name
+					)
+					select id, name from deleted
+				`,
+				"GetUserName": `
+					-- :one
+					-- $1: id
+					select ( select name from users where id = $1 ) as name
+				`,
+				"GetUserOrderTotals": `
+					-- :many
+					select
+						u.id,
+						( select sum(o.total) from orders o where o.user_id = u.id ) as total
+					from users u
+				`,
+				"GetUserOrderTotalsOrZero": `
+					-- :many
+					select
+						u.id,
+						coalesce(( select sum(o.total) from orders o where o.user_id = u.id ), 0) as total
+					from users u
+				`,
+				"GetUsersWithOrders": `
+					-- :many
+					select
+						u.id,
+						exists ( select 1 from orders o where o.user_id = u.id ) as has_orders
+					from users u
+				`,
+				"GetNextOrderID": `
+					-- :one
+					select ( select max(id) from orders ) + 1 as next_id
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "bool",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int8",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "text",
+				},
+			},
+			expectedQueries: map[string]engine.Query{
+				"GetManagedUsers": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "users.id",
+						},
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+							Column: "users.name",
+						},
+					},
+				},
+				"GetReportingChain": {
+					Type: engine.QueryTypeMany,
+					Inputs: []engine.Input{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+						{
+							Name: "manager_id",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int4",
+								Nullable: true,
+							},
+						},
+					},
+				},
+				"DeleteUnmanagedUsers": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "users.id",
+						},
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+							Column: "users.name",
+						},
+					},
+				},
+				"GetUserName": {
+					Type: engine.QueryTypeOne,
+					Inputs: []engine.Input{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+					},
+				},
+				"GetUserOrderTotals": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "users.id",
+						},
+						{
+							Name: "total",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int8",
+								Nullable: true,
+							},
+						},
+					},
+				},
+				"GetUserOrderTotalsOrZero": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "users.id",
+						},
+						{
+							Name: "total",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int8",
+							},
+						},
+					},
+				},
+				"GetUsersWithOrders": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "users.id",
+						},
+						{
+							Name: "has_orders",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "bool",
+							},
+						},
+					},
+				},
+				"GetNextOrderID": {
+					Type:   engine.QueryTypeOne,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "next_id",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int4",
+								Nullable: true,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SetReturningFunctions",
+			queries: map[string]string{
+				"GetSeries": `
+					-- :many
+					-- $1: stop
+					select n from generate_series(1, $1) as n
+				`,
+				"UnnestIDs": `
+					-- :many
+					-- $1: ids
+					select id from unnest($1::int[]) as id
+				`,
+				"GetRecords": `
+					-- :many
+					-- $1: records
+					select * from jsonb_to_recordset($1) as x(a int, b text)
+				`,
+				"ProjectSeries": `
+					-- :many
+					select generate_series(1, 3) as n
+				`,
+				"ProjectUnnest": `
+					-- :many
+					-- $1: ids
+					select unnest($1::int[]) as id
+				`,
+				"GetValues": `
+					-- :many
+					select * from ( values (1, 'a'), (2, null) ) as v(id, name)
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "jsonb",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "text",
+				},
+			},
+			expectedQueries: map[string]engine.Query{
+				"GetSeries": {
+					Type: engine.QueryTypeMany,
+					Inputs: []engine.Input{
+						{
+							Name: "stop",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "n",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int4",
+								Nullable: true,
+							},
+						},
+					},
+				},
+				"UnnestIDs": {
+					Type: engine.QueryTypeMany,
+					Inputs: []engine.Input{
+						{
+							Name: "ids",
+							Type: engine.Type{
+								Kind: engine.TypeKindArray,
+								Name: "_int4",
+								Elem: &engine.Type{
+									Kind:     engine.TypeKindBase,
+									Name:     "int4",
+									Nullable: true,
+								},
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int4",
+								Nullable: true,
+							},
+						},
+					},
+				},
+				"GetRecords": {
+					Type: engine.QueryTypeMany,
+					Inputs: []engine.Input{
+						{
+							Name: "records",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "jsonb",
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "a",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int4",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "b",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+					},
+				},
+				"ProjectSeries": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "n",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int4",
+								Nullable: true,
+							},
+						},
+					},
+				},
+				"ProjectUnnest": {
+					Type: engine.QueryTypeMany,
+					Inputs: []engine.Input{
+						{
+							Name: "ids",
+							Type: engine.Type{
+								Kind: engine.TypeKindArray,
+								Name: "_int4",
+								Elem: &engine.Type{
+									Kind:     engine.TypeKindBase,
+									Name:     "int4",
+									Nullable: true,
+								},
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int4",
+								Nullable: true,
+							},
+						},
+					},
+				},
+				"GetValues": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int4",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "OuterSemiAndAntiJoins",
+			schema: `
+				create table employees ( id int not null, name text not null, department_id int );
+				create table departments ( id int not null, name text not null );
+			`,
+			queries: map[string]string{
+				"GetEmployeesAndDepartments": `
+					-- :many
+					select
+						e.name as employee_name,
+						d.name as department_name
+					from employees e
+					full join departments d
+					on e.department_id = d.id
+				`,
+				"GetStaffedDepartments": `
+					-- :many
+					select d.id, d.name
+					from departments d
+					where exists ( select 1 from employees e where e.department_id = d.id )
+				`,
+				"GetEmptyDepartments": `
+					-- :many
+					select d.id, d.name
+					from departments d
+					where not exists ( select 1 from employees e where e.department_id = d.id )
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "text",
+				},
+			},
+			expectedQueries: map[string]engine.Query{
+				"GetEmployeesAndDepartments": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "employee_name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+							Column: "employees.name",
+						},
+						{
+							Name: "department_name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+							Column: "departments.name",
+						},
+					},
+				},
+				"GetStaffedDepartments": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "departments.id",
+						},
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+							Column: "departments.name",
+						},
+					},
+				},
+				"GetEmptyDepartments": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "departments.id",
+						},
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+							Column: "departments.name",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "QuotedIdentifiers",
+			schema: `
+				create table "Users" ( "Id" int not null, "Display Name" text, "Manager Id" int );
+			`,
+			queries: map[string]string{
+				"GetUsers": `
+					-- :many
+					select "Id", "Display Name" from "Users"
+				`,
+				"GetUsersWithManagers": `
+					-- :many
+					select
+						u."Display Name" as "Name",
+						m."Id" as "ManagerId"
+					from "Users" u
+					left join "Users" m
+					on u."Manager Id" = m."Id"
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "text",
+				},
+			},
+			expectedQueries: map[string]engine.Query{
+				"GetUsers": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "Id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "Users.Id",
+						},
+						{
+							Name: "Display Name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+							Column: "Users.Display Name",
+						},
+					},
+				},
+				"GetUsersWithManagers": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "Name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+							Column: "Users.Display Name",
+						},
+						{
+							Name: "ManagerId",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int4",
+								Nullable: true,
+							},
+							Column: "Users.Id",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Expressions",
+			schema: `
+				create table users ( id int not null, name text, email text not null );
+			`,
+			queries: map[string]string{
+				"GetDisplayNames": `
+					-- :many
+					select coalesce(name, email) as display_name, coalesce(name, name) as maybe_name from users
+				`,
+				"GetDefaultedNames": `
+					-- :many
+					select coalesce(name, '') as name from users
+				`,
+				"GetLoweredNames": `
+					-- :many
+					select lower(email) as email, lower(name) as name from users
+				`,
+				"GetLabels": `
+					-- :many
+					select
+						case when name is null then 'anonymous' end as label,
+						case when name is null then 'anonymous' else name end as fallback,
+						case when id > 1 then 'big' else 'small' end as size
+					from users
+				`,
+				"GetConstants": `
+					-- :one
+					select 1 as one, 'otter' as name, null::text as nothing, now() as at
+				`,
+				"GetOperators": `
+					-- :many
+					select id + 1 as next_id, name || '!' as shout, name is null as anonymous from users
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "bool",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "text",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "timestamptz",
+				},
+			},
+			expectedQueries: map[string]engine.Query{
+				"GetDisplayNames": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "display_name",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+						},
+						{
+							Name: "maybe_name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+					},
+				},
+				"GetDefaultedNames": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+						},
+					},
+				},
+				"GetLoweredNames": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "email",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+						},
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+					},
+				},
+				"GetLabels": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "label",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "fallback",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "size",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+						},
+					},
+				},
+				"GetConstants": {
+					Type:   engine.QueryTypeOne,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "one",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+						},
+						{
+							Name: "nothing",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "at",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "timestamptz",
+							},
+						},
+					},
+				},
+				"GetOperators": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "next_id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+						{
+							Name: "shout",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "anonymous",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "bool",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "AssignedInputs",
+			schema: `
+				create table users ( id int primary key, name text, email text not null );
+			`,
+			queries: map[string]string{
+				"UpdateUser": `
+					-- :exec
+					-- $1: name
+					-- $2: email
+					-- $3: id
+					update users set name = $1, email = $2 where id = $3
+				`,
+				"InsertUserFromSelect": `
+					-- :exec
+					-- $1: id
+					-- $2: name
+					-- $3: email
+					insert into users (id, name, email) select $1, $2, $3
+				`,
+				"UpsertUser": `
+					-- :exec
+					-- $1: id
+					-- $2: name
+					-- $3: email
+					-- $4: new_name
+					insert into users (id, name, email) values ($1, $2, $3)
+					on conflict (id) do update set name = $4
+				`,
+				"InsertUsers": `
+					-- :exec
+					-- $1: email
+					-- $2: name
+					-- $3: id
+					-- $4: other_email
+					-- $5: other_name
+					-- $6: other_id
+					insert into users (email, name, id) values ($1, $2, $3), ($4, $5, $6)
+				`,
+				"UpdateUserQuoted": `
+					-- :exec
+					-- $1: name
+					-- $2: id
+					update users set email = E'it\'s $$where$$', name = $1 where id = $2
+				`,
+				"MergeUser": `
+					-- :exec
+					-- $1: id
+					-- $2: name
+					-- $3: email
+					merge into users u
+					using (select $1::int as id) s on u.id = s.id
+					when matched then update set name = $2, email = $3
+					when not matched then insert (id, email) values (s.id, $3)
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "text",
+				},
+			},
+			expectedQueries: map[string]engine.Query{
+				"UpdateUser": {
+					Type: engine.QueryTypeExec,
+					Inputs: []engine.Input{
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "email",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+						},
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+					Outputs: []engine.Output{},
+				},
+				"InsertUserFromSelect": {
+					Type: engine.QueryTypeExec,
+					Inputs: []engine.Input{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "email",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+						},
+					},
+					Outputs: []engine.Output{},
+				},
+				"InsertUsers": {
+					Type: engine.QueryTypeExec,
+					Inputs: []engine.Input{
+						{
+							Name: "email",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+						},
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+						{
+							Name: "other_email",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+						},
+						{
+							Name: "other_name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "other_id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+					Outputs: []engine.Output{},
+				},
+				"UpdateUserQuoted": {
+					Type: engine.QueryTypeExec,
+					Inputs: []engine.Input{
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+					Outputs: []engine.Output{},
+				},
+				"MergeUser": {
+					Type: engine.QueryTypeExec,
+					Inputs: []engine.Input{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "email",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+						},
+					},
+					Outputs: []engine.Output{},
+				},
+				"UpsertUser": {
+					Type: engine.QueryTypeExec,
+					Inputs: []engine.Input{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "email",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+						},
+						{
+							Name: "new_name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+					},
+					Outputs: []engine.Output{},
+				},
+			},
+		},
+		{
+			name: "InputAnnotations",
+			schema: `
+				create table users ( id int not null, name text );
+			`,
+			queries: map[string]string{
+				"SearchUsers": `
+					-- :many
+					-- $1?: name
+					select id from users where name = $1
+				`,
+				"RenameUser": `
+					-- :exec
+					-- $1!: name
+					-- $2: id
+					update users set name = $1 where id = $2
+				`,
+				"GetUsersAfter": `
+					-- :many
+					-- $1: after :: int8
+					select id from users where id > $1
+				`,
+				"IsMissing": `
+					-- :one
+					-- $1?: value :: int8
+					select $1 is null as missing
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "bool",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int8",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "text",
+				},
+			},
+			expectedQueries: map[string]engine.Query{
+				"IsMissing": {
+					SQL:  "-- :one\n\t\t\t\t\t-- $1?: value :: int8\n\t\t\t\t\tselect ($1::int8) is null as missing",
+					Type: engine.QueryTypeOne,
+					Inputs: []engine.Input{
+						{
+							Name: "value",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int8",
+								Nullable: true,
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "missing",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "bool",
+							},
+						},
+					},
+				},
+				"SearchUsers": {
+					Type: engine.QueryTypeMany,
+					Inputs: []engine.Input{
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "users.id",
+						},
+					},
+				},
+				"RenameUser": {
+					Type: engine.QueryTypeExec,
+					Inputs: []engine.Input{
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+						},
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+					Outputs: []engine.Output{},
+				},
+				"GetUsersAfter": {
+					SQL:  "-- :many\n\t\t\t\t\t-- $1: after :: int8\n\t\t\t\t\tselect id from users where id > ($1::int8)",
+					Type: engine.QueryTypeMany,
+					Inputs: []engine.Input{
+						{
+							Name: "after",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int8",
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "users.id",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "OutputAnnotations",
+			schema: `
+				create table users ( id int not null, name text );
+			`,
+			queries: map[string]string{
+				"GetUsers": `
+					-- :many
+					select
+						name as "name!",
+						id as "id?",
+						coalesce(name, '') as "label?"
+					from users
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "text",
+				},
+			},
+			expectedQueries: map[string]engine.Query{
+				"GetUsers": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+							Column: "users.name",
+						},
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int4",
+								Nullable: true,
+							},
+							Column: "users.id",
+						},
+						{
+							Name: "label",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "NamedInputs",
+			schema: `
+				create table users ( id int not null, org_id int not null, name text );
+			`,
+			queries: map[string]string{
+				"GetUser": `
+					-- :one
+					select id from users where id = @id and org_id = @org_id or id = @id
+				`,
+				"RenameUser": `
+					-- :exec
+					update users set name = sqlc.arg(name) where id = @id
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "text",
+				},
+			},
+			expectedQueries: map[string]engine.Query{
+				"GetUser": {
+					SQL:  "-- :one\n\t\t\t\t\tselect id from users where id = $1 and org_id = $2 or id = $1",
+					Type: engine.QueryTypeOne,
+					Inputs: []engine.Input{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+						{
+							Name: "org_id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "users.id",
+						},
+					},
+				},
+				"RenameUser": {
+					SQL:  "-- :exec\n\t\t\t\t\tupdate users set name = $1 where id = $2",
+					Type: engine.QueryTypeExec,
+					Inputs: []engine.Input{
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+					Outputs: []engine.Output{},
+				},
+			},
+		},
+		{
+			name: "ExecQueryTypes",
+			schema: `
+				create table users ( id int not null, name text );
+			`,
+			queries: map[string]string{
+				"RenameUsers": `
+					-- :execrows
+					-- $1: name
+					update users set name = $1
+				`,
+				"DeleteUser": `
+					-- :execresult
+					-- $1: id
+					delete from users where id = $1
+				`,
+				"InsertUser": `
+					-- $1: id
+					insert into users ( id ) values ( $1 )
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "text",
+				},
+			},
+			expectedQueries: map[string]engine.Query{
+				"RenameUsers": {
+					Type: engine.QueryTypeExecRows,
+					Inputs: []engine.Input{
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "text",
+								Nullable: true,
+							},
+						},
+					},
+					Outputs: []engine.Output{},
+				},
+				"DeleteUser": {
+					Type: engine.QueryTypeExecResult,
+					Inputs: []engine.Input{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+					Outputs: []engine.Output{},
+				},
+				"InsertUser": {
+					Type: engine.QueryTypeExec,
+					Inputs: []engine.Input{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+					Outputs: []engine.Output{},
+				},
+			},
+		},
+		{
+			name: "SingleRowQueries",
+			schema: `
+				create table users ( id int primary key, name text not null );
+				create table memberships ( user_id int not null, group_id int not null, primary key ( user_id, group_id ) );
+			`,
+			queries: map[string]string{
+				"GetUser": `
+					-- $1: id
+					select name from users where id = $1
+				`,
+				"ListUsersByName": `
+					-- $1: name
+					select id from users where name = $1
+				`,
+				"GetMembership": `
+					-- $1: user_id
+					-- $2: group_id
+					select group_id from memberships where user_id = $1 and group_id = $2
+				`,
+				"ListMemberships": `
+					-- $1: user_id
+					select group_id from memberships where user_id = $1
+				`,
+				"CountUsers": `
+					select count(*) from users
+				`,
+				"GetFirstUserByName": `
+					-- $1: name
+					select id from users where name = $1 order by id limit 1
+				`,
+				"ListFirstUsersByName": `
+					-- $1: name
+					select id from users where name = $1 order by id limit 10
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int8",
+				},
+				{
+					Kind: engine.TypeKindBase,
+					Name: "text",
+				},
+			},
+			expectedQueries: map[string]engine.Query{
+				"GetUser": {
+					Type: engine.QueryTypeOne,
+					Inputs: []engine.Input{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+							Column: "users.name",
+						},
+					},
+				},
+				"ListUsersByName": {
+					Type: engine.QueryTypeMany,
+					Inputs: []engine.Input{
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "users.id",
+						},
+					},
+				},
+				"GetFirstUserByName": {
+					Type: engine.QueryTypeMany,
+					Inputs: []engine.Input{
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "users.id",
+						},
+					},
+				},
+				"ListFirstUsersByName": {
+					Type: engine.QueryTypeMany,
+					Inputs: []engine.Input{
+						{
+							Name: "name",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "text",
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "users.id",
+						},
+					},
+				},
+				"GetMembership": {
+					Type: engine.QueryTypeOne,
+					Inputs: []engine.Input{
+						{
+							Name: "user_id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+						{
+							Name: "group_id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "group_id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "memberships.group_id",
+						},
+					},
+				},
+				"ListMemberships": {
+					Type: engine.QueryTypeMany,
+					Inputs: []engine.Input{
+						{
+							Name: "user_id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+						},
+					},
+					Outputs: []engine.Output{
+						{
+							Name: "group_id",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int4",
+							},
+							Column: "memberships.group_id",
+						},
+					},
+				},
+				"CountUsers": {
+					Type:   engine.QueryTypeOne,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "count",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "int8",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "NumericModifiers",
+			schema: `
+				create domain amount as numeric(12,2);
+				create table payments ( total numeric(12,2) not null, fee numeric(5), rate numeric not null, paid amount not null, history numeric(8,3)[] not null );
+			`,
+			queries: map[string]string{
+				"GetPayments": `
+					-- :many
+					select total, fee, rate, paid, history, total::numeric(10,4) as converted from payments
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "numeric",
+				},
+				amountType,
+			},
+			expectedQueries: map[string]engine.Query{
+				"GetPayments": {
+					Type:   engine.QueryTypeMany,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Name: "total",
+							Type: engine.Type{
+								Kind:      engine.TypeKindBase,
+								Name:      "numeric",
+								Precision: 12,
+								Scale:     2,
+							},
+							Column: "payments.total",
+						},
+						{
+							Name: "fee",
+							Type: engine.Type{
+								Kind:      engine.TypeKindBase,
+								Name:      "numeric",
+								Nullable:  true,
+								Precision: 5,
+							},
+							Column: "payments.fee",
+						},
+						{
+							Name: "rate",
+							Type: engine.Type{
+								Kind: engine.TypeKindBase,
+								Name: "numeric",
+							},
+							Column: "payments.rate",
+						},
+						{
+							Name:   "paid",
+							Type:   amountType,
+							Column: "payments.paid",
+						},
+						{
+							Name: "history",
+							Type: engine.Type{
+								Kind: engine.TypeKindArray,
+								Name: "_numeric",
+								Elem: &engine.Type{
+									Kind:      engine.TypeKindBase,
+									Name:      "numeric",
+									Nullable:  true,
+									Precision: 8,
+									Scale:     3,
+								},
+							},
+							Column: "payments.history",
+						},
+						{
+							Name: "converted",
+							Type: engine.Type{
+								Kind:      engine.TypeKindBase,
+								Name:      "numeric",
+								Precision: 10,
+								Scale:     4,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "NoSchema",
+			queries: map[string]string{
+				"GetOne": `
+					-- :one
+					select 1
+				`,
+			},
+			expectedTypes: []engine.Type{
+				{
+					Kind: engine.TypeKindBase,
+					Name: "int4",
+				},
+			},
+			expectedQueries: map[string]engine.Query{
+				"GetOne": {
+					Type:   engine.QueryTypeOne,
+					Inputs: []engine.Input{},
+					Outputs: []engine.Output{
+						{
+							Type: engine.Type{
+								Kind:     engine.TypeKindBase,
+								Name:     "int4",
+								Nullable: false,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		// Rather than hand-write the expected SQL, we're going to generate
+		// it here unless the query is expected to be rewritten.
+		for queryName, query := range tt.queries {
+			expectedQuery := tt.expectedQueries[queryName]
+			expectedQuery.Name = queryName
+			if expectedQuery.SQL == "" {
+				expectedQuery.SQL = strings.TrimSpace(query)
+			}
+			tt.expectedQueries[queryName] = expectedQuery
+		}
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := mustCreateDB(t, tt.schema)
+			e := pgengine.New(db)
+
+			resolved, err := e.ResolveQueries(t.Context(), querySources(tt.queries))
+			require.Nil(t, err)
+
+			assert.ElementsMatch(t, resolved.Types, tt.expectedTypes)
+			assert.Equal(t, resolved.Queries, tt.expectedQueries)
+		})
+	}
+
+}
+
+func TestUnknownInputAnnotation(t *testing.T) {
+	t.Parallel()
+
+	db := mustCreateDB(t, `create table users ( id int not null );`)
+	e := pgengine.New(db)
+
+	_, err := e.ResolveQueries(t.Context(), querySources(map[string]string{
+		"GetUser": `
+			-- :one
+			-- $1: id
+			-- $2: name
+			select id from users where id = $1
+		`,
+	}))
+	require.ErrorContains(t, err, "annotation '$2' does not match any parameter of the query")
+}
+
+func TestElemNullabilityOfNonArray(t *testing.T) {
+	t.Parallel()
+
+	db := mustCreateDB(t, `create table users ( id int not null );`)
+	e := pgengine.New(db)
+
+	_, err := e.ResolveQueries(t.Context(), querySources(map[string]string{
+		"GetUser": `
+			-- :one
+			-- $1[!]: id
+			select id from users where id = $1
+		`,
+	}))
+	require.ErrorContains(t, err, "element nullability annotated on type 'int4', which is not an array")
+}
+
+func TestRowsAnnotationWithoutColumns(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		query       string
+		expectedErr string
+	}{
+		{
+			name: "One",
+			query: `
+				-- :one
+				-- $1: id
+				delete from users where id = $1
+			`,
+			expectedErr: "query 'ModifyUsers' is annotated :one but does not return any columns",
+		},
+		{
+			name: "Many",
+			query: `
+				-- :many
+				-- $1: name
+				update users set name = $1
+			`,
+			expectedErr: "query 'ModifyUsers' is annotated :many but does not return any columns",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := mustCreateDB(t, `create table users ( id int primary key, name text not null );`)
+			e := pgengine.New(db)
+
+			_, err := e.ResolveQueries(t.Context(), querySources(map[string]string{
+				"ModifyUsers": tt.query,
+			}))
+			require.ErrorContains(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestSingleRowWarning(t *testing.T) {
+	t.Parallel()
+
+	db := mustCreateDB(t, `create table users ( id int primary key, name text not null );`)
+	e := pgengine.New(db)
+
+	resolved, err := e.ResolveQueries(t.Context(), querySources(map[string]string{
+		"GetUserByName": `
+			-- :one
+			-- $1: name
+			select id from users where name = $1
+		`,
+	}))
+	require.Nil(t, err)
+
+	assert.Equal(t, []string{"query 'GetUserByName' is annotated :one but may return more than one row"}, resolved.Warnings)
+}
+
+func TestHeaderErrorPosition(t *testing.T) {
+	t.Parallel()
+
+	db := mustCreateDB(t, `create table users ( id int not null );`)
+	e := pgengine.New(db)
+
+	_, err := e.ResolveQueries(t.Context(), map[string]engine.QuerySource{
+		"GetUser": {
+			SQL:  "-- name: GetUser :one\n-- :manyy\nselect id from users",
+			File: "queries/users.sql",
+			Line: 7,
+		},
+	})
+	require.ErrorContains(t, err, "queries/users.sql:8: unknown query type ':manyy'")
+}
+
+// querySources reads each query as though it were the only query in a
+// file named after it.
+func querySources(queries map[string]string) map[string]engine.QuerySource {
+	sources := make(map[string]engine.QuerySource, len(queries))
+	for queryName, query := range queries {
+		sources[queryName] = engine.QuerySource{SQL: query, File: queryName + ".sql", Line: 1}
+	}
+
+	return sources
+}
 
+func nullable(typ engine.Type) engine.Type {
+	typ.Nullable = true
+	return typ
 }
 
 func mustCreateDB(t *testing.T, schema string) *pgx.Conn {
@@ -0,0 +1,362 @@
+package pgprinter
+
+import (
+	"github.com/DanielleMaywood/otter/internal/printer"
+	"github.com/dave/jennifer/jen"
+	"github.com/iancoleman/strcase"
+)
+
+// baseType is the Go type a built-in Postgres base type is generated as.
+type baseType struct {
+	goType jen.Code
+
+	// nullable reports whether the Go type can represent a null value
+	// itself, in which case it is never wrapped in sql.Null. This is also
+	// used for types that sql.Null cannot scan into.
+	nullable bool
+
+	// nullGoType is the Go type of nullable values, when it is neither
+	// the Go type itself nor the Go type wrapped in sql.Null.
+	nullGoType jen.Code
+
+	// scanner reports whether the Go type implements sql.Scanner and
+	// driver.Valuer, which domains over the type need to forward.
+	scanner bool
+
+	// printMethods prints the Scan and Value methods of a type defined
+	// over the Go type, for Go types that pgx cannot scan into directly.
+	printMethods func(file *jen.File, typeName string)
+}
+
+// baseTypes maps the core pg_catalog base types to the Go types that pgx
+// scans them into. Types pgx has no codec for are exchanged in their text
+// representation, and so are generated as strings.
+var baseTypes = normalizeBaseTypes(map[string]baseType{
+	"bool": {goType: jen.Bool()},
+	"char": {goType: jen.Byte()},
+
+	"int2":   {goType: jen.Int16()},
+	"int4":   {goType: jen.Int32()},
+	"int8":   {goType: jen.Int64()},
+	"float4": {goType: jen.Float32()},
+	"float8": {goType: jen.Float64()},
+
+	"numeric": {goType: jen.Qual("github.com/jackc/pgx/v5/pgtype", "Numeric"), nullable: true, scanner: true},
+	"money":   {goType: jen.String()},
+
+	"oid":  {goType: jen.Uint32()},
+	"xid":  {goType: jen.Uint32()},
+	"cid":  {goType: jen.Uint32()},
+	"xid8": {goType: jen.Uint64()},
+
+	"regclass":      {goType: jen.String()},
+	"regcollation":  {goType: jen.String()},
+	"regconfig":     {goType: jen.String()},
+	"regdictionary": {goType: jen.String()},
+	"regnamespace":  {goType: jen.String()},
+	"regoper":       {goType: jen.String()},
+	"regoperator":   {goType: jen.String()},
+	"regproc":       {goType: jen.String()},
+	"regprocedure":  {goType: jen.String()},
+	"regrole":       {goType: jen.String()},
+	"regtype":       {goType: jen.String()},
+
+	"text":      {goType: jen.String()},
+	"varchar":   {goType: jen.String()},
+	"bpchar":    {goType: jen.String()},
+	"name":      {goType: jen.String()},
+	"refcursor": {goType: jen.String()},
+
+	"bytea": {goType: jen.Index().Byte(), nullable: true},
+
+	"json":     {goType: jen.Qual("encoding/json", "RawMessage"), nullable: true},
+	"jsonb":    {goType: jen.Qual("encoding/json", "RawMessage"), nullable: true},
+	"jsonpath": {goType: jen.String()},
+	"xml":      {goType: jen.String()},
+
+	"uuid": {goType: jen.Qual("github.com/jackc/pgx/v5/pgtype", "UUID"), nullable: true, scanner: true},
+
+	"date":        {goType: jen.Qual("time", "Time")},
+	"time":        {goType: jen.Qual("github.com/jackc/pgx/v5/pgtype", "Time"), nullable: true, scanner: true},
+	"timetz":      {goType: jen.String()},
+	"timestamp":   {goType: jen.Qual("time", "Time")},
+	"timestamptz": {goType: jen.Qual("time", "Time")},
+	"interval":    {goType: jen.Qual("github.com/jackc/pgx/v5/pgtype", "Interval"), nullable: true, scanner: true},
+
+	"inet":     {goType: jen.Qual("net/netip", "Prefix"), nullable: true},
+	"cidr":     {goType: jen.Qual("net/netip", "Prefix"), nullable: true},
+	"macaddr":  {goType: jen.Qual("net", "HardwareAddr"), nullable: true},
+	"macaddr8": {goType: jen.Qual("net", "HardwareAddr"), nullable: true},
+
+	"bit":    {goType: jen.Qual("github.com/jackc/pgx/v5/pgtype", "Bits"), nullable: true, scanner: true},
+	"varbit": {goType: jen.Qual("github.com/jackc/pgx/v5/pgtype", "Bits"), nullable: true, scanner: true},
+
+	"point":   {goType: jen.Qual("github.com/jackc/pgx/v5/pgtype", "Point"), nullable: true, scanner: true},
+	"line":    {goType: jen.Qual("github.com/jackc/pgx/v5/pgtype", "Line"), nullable: true, scanner: true},
+	"lseg":    {goType: jen.Qual("github.com/jackc/pgx/v5/pgtype", "Lseg"), nullable: true, scanner: true},
+	"box":     {goType: jen.Qual("github.com/jackc/pgx/v5/pgtype", "Box"), nullable: true, scanner: true},
+	"path":    {goType: jen.Qual("github.com/jackc/pgx/v5/pgtype", "Path"), nullable: true, scanner: true},
+	"polygon": {goType: jen.Qual("github.com/jackc/pgx/v5/pgtype", "Polygon"), nullable: true, scanner: true},
+	"circle":  {goType: jen.Qual("github.com/jackc/pgx/v5/pgtype", "Circle"), nullable: true, scanner: true},
+
+	"tid": {goType: jen.Qual("github.com/jackc/pgx/v5/pgtype", "TID"), nullable: true, scanner: true},
+
+	"tsvector":      {goType: jen.String()},
+	"tsquery":       {goType: jen.String()},
+	"pg_lsn":        {goType: jen.String()},
+	"pg_snapshot":   {goType: jen.String()},
+	"txid_snapshot": {goType: jen.String()},
+	"aclitem":       {goType: jen.String()},
+})
+
+// normalizeBaseTypes converts the type names in the same way as the
+// override keys, as type names reach the printer in Go casing.
+func normalizeBaseTypes(types map[string]baseType) map[string]baseType {
+	normalized := make(map[string]baseType, len(types))
+	for name, typ := range types {
+		normalized[strcase.ToSnake(name)] = typ
+	}
+
+	return normalized
+}
+
+// baseType returns the Go type that a base type is generated as, taking
+// the type options into account.
+func (p Printer) baseType(typeName string) (baseType, bool) {
+	name := strcase.ToSnake(typeName)
+
+	if name == "numeric" {
+		switch p.typeOptions.Numeric {
+		case printer.NumericTypeDecimal:
+			return baseType{
+				goType:     jen.Qual("github.com/shopspring/decimal", "Decimal"),
+				nullGoType: jen.Qual("github.com/shopspring/decimal", "NullDecimal"),
+				scanner:    true,
+			}, true
+
+		case printer.NumericTypeBigRat:
+			return baseType{
+				goType:       jen.Qual("math/big", "Rat"),
+				scanner:      true,
+				printMethods: printRatMethods,
+			}, true
+
+		case printer.NumericTypeString:
+			return baseType{goType: jen.String()}, true
+		}
+	}
+
+	switch {
+	case name == "timestamptz" && p.typeOptions.Timestamptz == printer.TimestamptzTypeUTC:
+		return baseType{
+			goType:  jen.Struct(jen.Qual("time", "Time")),
+			scanner: true,
+			printMethods: func(file *jen.File, typeName string) {
+				printTimeMethods(file, typeName, "Time", "Timestamptz",
+					jen.Id("s").Dot("UTC").Call(),
+					jen.Id("t").Dot("Time").Dot("UTC").Call(),
+				)
+			},
+		}, true
+
+	case name == "timestamptz" && p.typeOptions.Timestamptz == printer.TimestamptzTypePgtype:
+		return baseType{goType: jen.Qual("github.com/jackc/pgx/v5/pgtype", "Timestamptz"), nullable: true, scanner: true}, true
+
+	case name == "timestamp" && p.typeOptions.Timestamp == printer.TimestampTypeCivil:
+		return baseType{
+			goType:  jen.Struct(jen.Qual("cloud.google.com/go/civil", "DateTime")),
+			scanner: true,
+			printMethods: func(file *jen.File, typeName string) {
+				printTimeMethods(file, typeName, "DateTime", "Timestamp",
+					jen.Qual("cloud.google.com/go/civil", "DateTimeOf").Call(jen.Id("s")),
+					jen.Id("t").Dot("DateTime").Dot("In").Call(jen.Qual("time", "UTC")),
+				)
+			},
+		}, true
+
+	case name == "timestamp" && p.typeOptions.Timestamp == printer.TimestampTypePgtype:
+		return baseType{goType: jen.Qual("github.com/jackc/pgx/v5/pgtype", "Timestamp"), nullable: true, scanner: true}, true
+
+	case name == "date" && p.typeOptions.Date == printer.DateTypeCivil:
+		return baseType{
+			goType:  jen.Struct(jen.Qual("cloud.google.com/go/civil", "Date")),
+			scanner: true,
+			printMethods: func(file *jen.File, typeName string) {
+				printTimeMethods(file, typeName, "Date", "Date",
+					jen.Qual("cloud.google.com/go/civil", "DateOf").Call(jen.Id("s")),
+					jen.Id("t").Dot("Date").Dot("In").Call(jen.Qual("time", "UTC")),
+				)
+			},
+		}, true
+
+	case name == "date" && p.typeOptions.Date == printer.DateTypePgtype:
+		return baseType{goType: jen.Qual("github.com/jackc/pgx/v5/pgtype", "Date"), nullable: true, scanner: true}, true
+
+	case name == "interval" && p.typeOptions.Interval == printer.IntervalTypeDuration:
+		return baseType{
+			goType:       jen.Struct(jen.Qual("time", "Duration")),
+			scanner:      true,
+			printMethods: printDurationMethods,
+		}, true
+	}
+
+	typ, found := baseTypes[name]
+	return typ, found
+}
+
+// printTimeMethods prints the methods for a date or timestamp held in the
+// field of a struct. pgx scans these as a time.Time, or as a string when
+// they are infinite or when they are read in their text representation,
+// such as the attributes of a composite type. The strings are parsed by
+// the pgtype named by pgType, and infinite values are rejected as the
+// field's type cannot represent them.
+func printTimeMethods(file *jen.File, typeName, fieldName, pgType string, fromTime, toTime jen.Code) {
+	file.Func().
+		Params(jen.Id("t").Op("*").Id(typeName)).
+		Id("Scan").
+		Params(jen.Id("src").Any()).
+		Error().
+		Block(
+			jen.Switch(jen.Id("s").Op(":=").Id("src").Assert(jen.Type())).Block(
+				jen.Case(jen.Qual("time", "Time")).Block(
+					jen.Id("t").Dot(fieldName).Op("=").Add(fromTime),
+					jen.Return(jen.Nil()),
+				),
+				jen.Case(jen.String()).Block(
+					jen.Var().Id("value").Qual("github.com/jackc/pgx/v5/pgtype", pgType),
+					jen.If(
+						jen.Err().Op(":=").Id("value").Dot("Scan").Call(jen.Id("s")),
+						jen.Err().Op("!=").Nil(),
+					).Block(
+						jen.Return(jen.Qual("fmt", "Errorf").Call(jen.Lit("scan "+typeName+": %w"), jen.Err())),
+					),
+					jen.Line(),
+					jen.If(jen.Id("value").Dot("InfinityModifier").Op("!=").Qual("github.com/jackc/pgx/v5/pgtype", "Finite")).Block(
+						jen.Return(jen.Qual("fmt", "Errorf").Call(
+							jen.Lit("scan "+typeName+": %s cannot be represented"),
+							jen.Id("s"),
+						)),
+					),
+					jen.Line(),
+					jen.Return(jen.Id("t").Dot("Scan").Call(jen.Id("value").Dot("Time"))),
+				),
+				jen.Default().Block(
+					jen.Return(jen.Qual("fmt", "Errorf").Call(
+						jen.Lit("unsupported scan type for "+typeName+": %T"),
+						jen.Id("src"),
+					)),
+				),
+			),
+		).
+		Line()
+
+	file.Func().
+		Params(jen.Id("t").Id(typeName)).
+		Id("Value").
+		Params().
+		Params(jen.Qual("database/sql/driver", "Value"), jen.Error()).
+		Block(
+			jen.Return(toTime, jen.Nil()),
+		).
+		Line()
+}
+
+// printDurationMethods prints the methods for an interval held in a
+// time.Duration. Intervals are exchanged in their text representation,
+// which pgtype.Interval parses for us.
+func printDurationMethods(file *jen.File, typeName string) {
+	file.Func().
+		Params(jen.Id("t").Op("*").Id(typeName)).
+		Id("Scan").
+		Params(jen.Id("src").Any()).
+		Error().
+		Block(
+			jen.Var().Id("interval").Qual("github.com/jackc/pgx/v5/pgtype", "Interval"),
+			jen.If(
+				jen.Err().Op(":=").Id("interval").Dot("Scan").Call(jen.Id("src")),
+				jen.Err().Op("!=").Nil(),
+			).Block(
+				jen.Return(jen.Qual("fmt", "Errorf").Call(jen.Lit("scan "+typeName+": %w"), jen.Err())),
+			),
+			jen.Line(),
+			jen.If(jen.Id("interval").Dot("Months").Op("!=").Lit(0)).Block(
+				jen.Return(jen.Qual("errors", "New").Call(
+					jen.Lit("scan "+typeName+": an interval of months cannot be represented"),
+				)),
+			),
+			jen.Line(),
+			jen.Id("t").Dot("Duration").Op("=").
+				Qual("time", "Duration").Call(jen.Id("interval").Dot("Days")).Op("*").Lit(24).Op("*").Qual("time", "Hour").Op("+").
+				Qual("time", "Duration").Call(jen.Id("interval").Dot("Microseconds")).Op("*").Qual("time", "Microsecond"),
+			jen.Return(jen.Nil()),
+		).
+		Line()
+
+	file.Func().
+		Params(jen.Id("t").Id(typeName)).
+		Id("Value").
+		Params().
+		Params(jen.Qual("database/sql/driver", "Value"), jen.Error()).
+		Block(
+			jen.Return(jen.Qual("github.com/jackc/pgx/v5/pgtype", "Interval").Values(jen.Dict{
+				jen.Id("Microseconds"): jen.Id("t").Dot("Duration").Dot("Microseconds").Call(),
+				jen.Id("Valid"):        jen.True(),
+			}).Dot("Value").Call()),
+		).
+		Line()
+}
+
+// printRatMethods prints the methods for a numeric defined over big.Rat.
+// Numerics are exchanged in their text representation, and only values
+// with an exact decimal representation can be sent to the database. Value
+// needs a value receiver for pgx to find it, but the receiver is then a
+// shallow copy sharing its digits with the caller's big.Rat, so it is
+// copied properly before it is used.
+func printRatMethods(file *jen.File, typeName string) {
+	file.Func().
+		Params(jen.Id("t").Op("*").Id(typeName)).
+		Id("Scan").
+		Params(jen.Id("src").Any()).
+		Error().
+		Block(
+			jen.List(jen.Id("s"), jen.Id("ok")).Op(":=").Id("src").Assert(jen.String()),
+			jen.If(jen.Op("!").Id("ok")).Block(
+				jen.Return(jen.Qual("fmt", "Errorf").Call(
+					jen.Lit("unsupported scan type for "+typeName+": %T"),
+					jen.Id("src"),
+				)),
+			),
+			jen.Line(),
+			jen.If(
+				jen.List(jen.Id("_"), jen.Id("ok")).Op(":=").Parens(jen.Op("*").Qual("math/big", "Rat")).Call(jen.Id("t")).Dot("SetString").Call(jen.Id("s")),
+				jen.Op("!").Id("ok"),
+			).Block(
+				jen.Return(jen.Qual("fmt", "Errorf").Call(
+					jen.Lit("scan "+typeName+": invalid number %q"),
+					jen.Id("s"),
+				)),
+			),
+			jen.Return(jen.Nil()),
+		).
+		Line()
+
+	file.Func().
+		Params(jen.Id("t").Id(typeName)).
+		Id("Value").
+		Params().
+		Params(jen.Qual("database/sql/driver", "Value"), jen.Error()).
+		Block(
+			jen.Id("rat").Op(":=").New(jen.Qual("math/big", "Rat")).Dot("Set").Call(
+				jen.Parens(jen.Op("*").Qual("math/big", "Rat")).Call(jen.Op("&").Id("t")),
+			),
+			jen.List(jen.Id("prec"), jen.Id("exact")).Op(":=").Id("rat").Dot("FloatPrec").Call(),
+			jen.If(jen.Op("!").Id("exact")).Block(
+				jen.Return(jen.Nil(), jen.Qual("fmt", "Errorf").Call(
+					jen.Lit("value "+typeName+": %s has no exact decimal representation"),
+					jen.Id("rat").Dot("RatString").Call(),
+				)),
+			),
+			jen.Return(jen.Id("rat").Dot("FloatString").Call(jen.Id("prec")), jen.Nil()),
+		).
+		Line()
+}
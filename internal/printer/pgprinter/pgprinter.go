@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"maps"
 	"slices"
+	"strings"
 	"sync"
 
 	"github.com/DanielleMaywood/otter/internal/buildinfo"
@@ -14,20 +15,73 @@ import (
 	"github.com/iancoleman/strcase"
 )
 
+type Option func(*Printer)
+
 type Printer struct {
 	packageName string
 	overrides   printer.TypeOverrides
+	typeOptions printer.TypeOptions
+	jsonTypes   printer.JSONTypes
+}
+
+func WithTypeOptions(typeOptions printer.TypeOptions) Option {
+	return func(p *Printer) {
+		p.typeOptions = typeOptions
+	}
+}
+
+func WithJSONTypes(jsonTypes printer.JSONTypes) Option {
+	return func(p *Printer) {
+		p.jsonTypes = printer.JSONTypes{
+			Columns: normalizeJSONTypes(jsonTypes.Columns),
+			Queries: normalizeJSONTypes(jsonTypes.Queries),
+		}
+	}
+}
+
+// normalizeJSONTypes converts each part of the keys in the same way as the
+// override keys, as names reach the printer in Go casing.
+func normalizeJSONTypes(jsonTypes map[string]printer.JSONType) map[string]printer.JSONType {
+	normalized := make(map[string]printer.JSONType, len(jsonTypes))
+	for key, jsonType := range jsonTypes {
+		normalized[jsonTypeKey(strings.Split(key, ".")...)] = jsonType
+	}
+
+	return normalized
 }
 
-func New(packageName string, overrides printer.TypeOverrides) Printer {
-	return Printer{packageName: packageName, overrides: overrides}
+func jsonTypeKey(parts ...string) string {
+	for idx, part := range parts {
+		parts[idx] = strcase.ToSnake(part)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func New(packageName string, overrides printer.TypeOverrides, opts ...Option) Printer {
+	// Type names reach the printer in Go casing, so the override keys are
+	// converted in the same way for lookups to find them again.
+	normalized := make(printer.TypeOverrides, len(overrides))
+	for name, override := range overrides {
+		normalized[strcase.ToSnake(name)] = override
+	}
+
+	p := Printer{packageName: packageName, overrides: normalized}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
 }
 
 var doNotEditComment = sync.OnceValue(func() string {
 	return fmt.Sprintf("Code generated by otter (%s). DO NOT EDIT.", buildinfo.Version())
 })
 
-func (p Printer) PrintQueries(queries engine.Result) printer.Result {
+func (p Printer) PrintQueries(queries engine.Result) (printer.Result, error) {
+	if err := p.checkQueryTypes(queries); err != nil {
+		return printer.Result{}, err
+	}
+
 	databaseFile := jen.NewFile(p.packageName)
 	queriesFile := jen.NewFile(p.packageName)
 	modelsFile := jen.NewFile(p.packageName)
@@ -62,7 +116,17 @@ func (p Printer) PrintQueries(queries engine.Result) printer.Result {
 	})
 
 	for _, typ := range queries.Types {
-		p.printType(modelsFile, typ)
+		if err := p.printType(modelsFile, typ); err != nil {
+			return printer.Result{}, fmt.Errorf("print type '%s': %w", typ.Name, err)
+		}
+	}
+
+	for _, typ := range p.collectArrayTypes(queries) {
+		p.printArrayType(modelsFile, typ)
+	}
+
+	if p.usesJSONTypes(queries) {
+		p.printTypedJSON(modelsFile)
 	}
 
 	// Sort the queries alphabetically for a stable order.
@@ -83,7 +147,126 @@ func (p Printer) PrintQueries(queries engine.Result) printer.Result {
 		Database: databaseFile.GoString(),
 		Queries:  queriesFile.GoString(),
 		Models:   modelsFile.GoString(),
+	}, nil
+}
+
+// checkQueryTypes returns an error naming the query and column of the
+// first value with a type that there is no Go type for.
+func (p Printer) checkQueryTypes(queries engine.Result) error {
+	queryNames := slices.Collect(maps.Keys(queries.Queries))
+	slices.SortStableFunc(queryNames, cmp.Compare)
+
+	for _, queryName := range queryNames {
+		query := queries.Queries[queryName]
+
+		for idx, input := range query.Inputs {
+			if typeName, found := p.unsupportedType(input.Type); found {
+				inputName := input.Name
+				if inputName == "" {
+					inputName = fmt.Sprintf("$%d", idx+1)
+				}
+
+				return fmt.Errorf("query '%s' parameter '%s': %w", queryName, inputName, unsupportedTypeError(typeName))
+			}
+
+			if _, found := p.jsonType(queryName, input.Name, ""); found && !isJSONType(input.Type) {
+				return fmt.Errorf("query '%s' parameter '%s': %w", queryName, input.Name, jsonTypeError(input.Type))
+			}
+		}
+
+		for idx, output := range query.Outputs {
+			if typeName, found := p.unsupportedType(output.Type); found {
+				outputName := output.Name
+				if outputName == "" {
+					outputName = fmt.Sprintf("Field%d", idx)
+				}
+
+				return fmt.Errorf("query '%s' column '%s': %w", queryName, outputName, unsupportedTypeError(typeName))
+			}
+
+			if _, found := p.jsonType(queryName, output.Name, output.Column); found && !isJSONType(output.Type) {
+				return fmt.Errorf("query '%s' column '%s': %w", queryName, output.Name, jsonTypeError(output.Type))
+			}
+		}
 	}
+
+	return nil
+}
+
+// unsupportedType returns the name of a base type without a Go type that
+// the type is built from.
+func (p Printer) unsupportedType(typ engine.Type) (string, bool) {
+	if _, found := p.overrides[strcase.ToSnake(typ.Name)]; found {
+		return "", false
+	}
+
+	for _, attribute := range typ.Attributes {
+		if typeName, found := p.unsupportedType(attribute.Type); found {
+			return typeName, true
+		}
+	}
+
+	for _, inner := range []*engine.Type{typ.Base, typ.Elem} {
+		if inner == nil {
+			continue
+		}
+
+		if typeName, found := p.unsupportedType(*inner); found {
+			return typeName, true
+		}
+	}
+
+	if _, found := p.baseType(typ.Name); typ.Kind == engine.TypeKindBase && !found {
+		return typ.Name, true
+	}
+
+	return "", false
+}
+
+func unsupportedTypeError(typeName string) error {
+	return fmt.Errorf("unsupported type '%s', an override is needed for it", strcase.ToSnake(typeName))
+}
+
+func jsonTypeError(typ engine.Type) error {
+	return fmt.Errorf("a json type is declared for it but it has type '%s'", strcase.ToSnake(typ.Name))
+}
+
+func isJSONType(typ engine.Type) bool {
+	name := strcase.ToSnake(typ.Name)
+	return typ.Kind == engine.TypeKindBase && (name == "json" || name == "jsonb")
+}
+
+// jsonType returns the Go type declared for a json or jsonb value, looking
+// for one declared on the query before one declared on the column.
+func (p Printer) jsonType(queryName, name, column string) (printer.JSONType, bool) {
+	if jsonType, found := p.jsonTypes.Queries[jsonTypeKey(queryName, name)]; found {
+		return jsonType, true
+	}
+
+	if column == "" {
+		return printer.JSONType{}, false
+	}
+
+	jsonType, found := p.jsonTypes.Columns[jsonTypeKey(strings.Split(column, ".")...)]
+	return jsonType, found
+}
+
+func (p Printer) usesJSONTypes(queries engine.Result) bool {
+	for _, query := range queries.Queries {
+		for _, input := range query.Inputs {
+			if _, found := p.jsonType(query.Name, input.Name, ""); found {
+				return true
+			}
+		}
+
+		for _, output := range query.Outputs {
+			if _, found := p.jsonType(query.Name, output.Name, output.Column); found {
+				return true
+			}
+		}
+	}
+
+	return false
 }
 
 func (p Printer) printQuery(file *jen.File, query engine.Query) jen.Code {
@@ -91,6 +274,12 @@ func (p Printer) printQuery(file *jen.File, query engine.Query) jen.Code {
 	case engine.QueryTypeExec:
 		return p.printExecQuery(file, query)
 
+	case engine.QueryTypeExecRows:
+		return p.printExecRowsQuery(file, query)
+
+	case engine.QueryTypeExecResult:
+		return p.printExecResultQuery(file, query)
+
 	case engine.QueryTypeOne:
 		return p.printOneQuery(file, query)
 
@@ -106,7 +295,7 @@ func (p Printer) printExecQuery(file *jen.File, query engine.Query) jen.Code {
 	params, args := p.buildQueryParamsAndArgs(file, query)
 
 	file.Func().
-		Params(jen.Id("s").Op("*").Id("Store")).
+		Params(jen.Id("q").Op("*").Id("Querier")).
 		Id(query.Name).
 		Params(
 			append([]jen.Code{jen.Id("ctx").Qual("context", "Context")}, params...)...,
@@ -115,7 +304,7 @@ func (p Printer) printExecQuery(file *jen.File, query engine.Query) jen.Code {
 		Block(
 			jen.List(jen.Id("_"), jen.Err()).
 				Op(":=").
-				Id("s").Dot("db").Dot("Exec").Call(
+				Id("q").Dot("db").Dot("Exec").Call(
 				append([]jen.Code{jen.Id("ctx"), jen.Lit(query.SQL)}, args...)...,
 			),
 			jen.Return(jen.Err()),
@@ -127,6 +316,58 @@ func (p Printer) printExecQuery(file *jen.File, query engine.Query) jen.Code {
 		Error()
 }
 
+func (p Printer) printExecRowsQuery(file *jen.File, query engine.Query) jen.Code {
+	params, args := p.buildQueryParamsAndArgs(file, query)
+
+	file.Func().
+		Params(jen.Id("q").Op("*").Id("Querier")).
+		Id(query.Name).
+		Params(
+			append([]jen.Code{jen.Id("ctx").Qual("context", "Context")}, params...)...,
+		).
+		Params(jen.Int64(), jen.Error()).
+		Block(
+			jen.List(jen.Id("tag"), jen.Err()).
+				Op(":=").
+				Id("q").Dot("db").Dot("Exec").Call(
+				append([]jen.Code{jen.Id("ctx"), jen.Lit(query.SQL)}, args...)...,
+			),
+			jen.If(jen.Err().Op("!=").Nil()).Block(
+				jen.Return(jen.Lit(0), jen.Err()),
+			),
+			jen.Return(jen.Id("tag").Dot("RowsAffected").Call(), jen.Nil()),
+		).
+		Line()
+
+	return jen.Id(query.Name).
+		Params(append([]jen.Code{jen.Id("ctx").Qual("context", "Context")}, params...)...).
+		Params(jen.Int64(), jen.Error())
+}
+
+func (p Printer) printExecResultQuery(file *jen.File, query engine.Query) jen.Code {
+	params, args := p.buildQueryParamsAndArgs(file, query)
+
+	file.Func().
+		Params(jen.Id("q").Op("*").Id("Querier")).
+		Id(query.Name).
+		Params(
+			append([]jen.Code{jen.Id("ctx").Qual("context", "Context")}, params...)...,
+		).
+		Params(jen.Qual("github.com/jackc/pgx/v5/pgconn", "CommandTag"), jen.Error()).
+		Block(
+			jen.Return(
+				jen.Id("q").Dot("db").Dot("Exec").Call(
+					append([]jen.Code{jen.Id("ctx"), jen.Lit(query.SQL)}, args...)...,
+				),
+			),
+		).
+		Line()
+
+	return jen.Id(query.Name).
+		Params(append([]jen.Code{jen.Id("ctx").Qual("context", "Context")}, params...)...).
+		Params(jen.Qual("github.com/jackc/pgx/v5/pgconn", "CommandTag"), jen.Error())
+}
+
 func (p Printer) printOneQuery(file *jen.File, query engine.Query) jen.Code {
 	resultType, scanRefs := p.maybePrintQueryRowType(file, query)
 	params, args := p.buildQueryParamsAndArgs(file, query)
@@ -212,7 +453,7 @@ func (p Printer) buildQueryParamsAndArgs(file *jen.File, query engine.Query) ([]
 			paramName = "arg0"
 		}
 
-		typeName := p.typeID(query.Inputs[0].Type)
+		typeName := p.valueTypeID(query.Name, query.Inputs[0].Name, "", query.Inputs[0].Type)
 
 		return []jen.Code{jen.Id(paramName).Add(typeName)}, []jen.Code{jen.Id(paramName)}
 	}
@@ -225,7 +466,7 @@ func (p Printer) buildQueryParamsAndArgs(file *jen.File, query engine.Query) ([]
 			inputName = fmt.Sprintf("Arg%d", idx)
 		}
 
-		fieldType := p.typeID(input.Type)
+		fieldType := p.valueTypeID(query.Name, input.Name, "", input.Type)
 
 		fields[idx] = jen.Id(inputName).Add(fieldType)
 		args[idx] = jen.Id("params").Dot(inputName)
@@ -285,8 +526,8 @@ func (p Printer) maybePrintQueryRowType(file *jen.File, query engine.Query) (jen
 		return jen.Id(query.Name + "Row"), p.buildQueryScanReferences(query)
 	}
 
-	outputType := query.Outputs[0].Type
-	resultType := p.typeID(outputType)
+	output := query.Outputs[0]
+	resultType := p.valueTypeID(query.Name, output.Name, output.Column, output.Type)
 	return resultType, []jen.Code{jen.Op("&").Id("item")}
 }
 
@@ -298,7 +539,7 @@ func (p Printer) printQueryRowType(file *jen.File, query engine.Query) {
 			outputName = fmt.Sprintf("Field%d", idx)
 		}
 
-		fieldType := p.typeID(output.Type)
+		fieldType := p.valueTypeID(query.Name, output.Name, output.Column, output.Type)
 
 		fields[idx] = jen.Id(outputName).Add(fieldType)
 	}
@@ -309,49 +550,53 @@ func (p Printer) printQueryRowType(file *jen.File, query engine.Query) {
 		Line()
 }
 
-func (p Printer) printType(file *jen.File, typ engine.Type) {
+func (p Printer) printType(file *jen.File, typ engine.Type) error {
 	override, found := p.overrides[strcase.ToSnake(typ.Name)]
 	if found && (!typ.Nullable && override.GoType != "" || typ.Nullable && override.Null != nil) {
-		return
+		return nil
 	}
 
 	switch typ.Kind {
 	case engine.TypeKindBase:
-		p.printBaseType(file, typ)
-		p.printNullableType(file, typ)
+		return p.printBaseType(file, typ)
 
 	case engine.TypeKindEnum:
 		p.printEnumType(file, typ)
 		p.printNullableType(file, typ)
 
+	case engine.TypeKindComposite:
+		p.printCompositeType(file, typ)
+		p.printNullableType(file, typ)
+
+	case engine.TypeKindDomain:
+		p.printDomainType(file, typ)
+
 	default:
 		panic(fmt.Sprintf("unexpected type kind: %s", typ.Kind))
 	}
-}
-
-func (p Printer) printBaseType(file *jen.File, typ engine.Type) {
-	switch typ.Name {
-	case "Int4":
-		file.Type().Id(typ.Name).Op("=").Int32().Line()
 
-	case "Text":
-		file.Type().Id(typ.Name).Op("=").String().Line()
-
-	case "Name":
-		file.Type().Id(typ.Name).Op("=").String().Line()
+	return nil
+}
 
-	case "Char":
-		file.Type().Id(typ.Name).Op("=").Byte().Line()
+// printBaseType prints a base type as an alias of its Go type, or as a
+// type defined over it when it needs methods of its own. Nullable values
+// use sql.Null or a nullable Go type, so there is no separate nullable
+// type for it.
+func (p Printer) printBaseType(file *jen.File, typ engine.Type) error {
+	base, found := p.baseType(typ.Name)
+	if !found {
+		return unsupportedTypeError(typ.Name)
+	}
 
-	case "Bool":
-		file.Type().Id(typ.Name).Op("=").Bool().Line()
+	if base.printMethods != nil {
+		file.Type().Id(typ.Name).Add(base.goType).Line()
+		base.printMethods(file, typ.Name)
+		return nil
+	}
 
-	case "Oid":
-		file.Type().Id(typ.Name).Op("=").Uint32().Line()
+	file.Type().Id(typ.Name).Op("=").Add(base.goType).Line()
 
-	default:
-		panic(fmt.Sprintf("unexpected base type: %s", typ.Name))
-	}
+	return nil
 }
 
 func (p Printer) printEnumType(file *jen.File, typ engine.Type) {
@@ -389,6 +634,416 @@ func (p Printer) printEnumType(file *jen.File, typ engine.Type) {
 		)
 }
 
+func (p Printer) printCompositeType(file *jen.File, typ engine.Type) {
+	fields := make([]jen.Code, len(typ.Attributes))
+	scanFields := make([]jen.Code, 0, len(typ.Attributes))
+	valueFields := make([]jen.Code, len(typ.Attributes))
+	for idx, attribute := range typ.Attributes {
+		fields[idx] = jen.Id(attribute.Name).Add(p.attributeTypeID(attribute.Type))
+
+		scanFields = append(scanFields,
+			jen.If(jen.Op("!").Id("scanner").Dot("Next").Call()).Block(
+				jen.Return(jen.Qual("fmt", "Errorf").Call(
+					jen.Lit("scan "+typ.Name+": missing attribute "+attribute.Name+": %w"),
+					jen.Id("scanner").Dot("Err").Call(),
+				)),
+			),
+			jen.If(
+				jen.Err().Op(":=").Id("m").Dot("Scan").Call(
+					jen.Lit(0),
+					jen.Qual("github.com/jackc/pgx/v5/pgtype", "TextFormatCode"),
+					jen.Id("scanner").Dot("Bytes").Call(),
+					jen.Op("&").Id("t").Dot(attribute.Name),
+				),
+				jen.Err().Op("!=").Nil(),
+			).Block(
+				jen.Return(jen.Qual("fmt", "Errorf").Call(
+					jen.Lit("scan "+typ.Name+"."+attribute.Name+": %w"),
+					jen.Err(),
+				)),
+			),
+		)
+
+		valueFields[idx] = jen.Id("builder").Dot("AppendValue").Call(jen.Lit(0), jen.Id("t").Dot(attribute.Name))
+	}
+
+	file.Type().Id(typ.Name).Struct(fields...).Line()
+
+	// Composite values are exchanged in their text representation, as
+	// pgx has no codec registered for the type's OID. Attribute OIDs
+	// are left as zero so that pgx picks a codec from the Go type.
+	file.Func().
+		Params(jen.Id("t").Op("*").Id(typ.Name)).
+		Id("Scan").
+		Params(jen.Id("src").Any()).
+		Error().
+		Block(
+			append([]jen.Code{
+				jen.Var().Id("buf").Index().Byte(),
+				jen.Switch(jen.Id("s").Op(":=").Id("src").Assert(jen.Type())).Block(
+					jen.Case(jen.Index().Byte()).Block(
+						jen.Id("buf").Op("=").Id("s"),
+					),
+					jen.Case(jen.String()).Block(
+						jen.Id("buf").Op("=").Index().Byte().Call(jen.Id("s")),
+					),
+					jen.Default().Block(
+						jen.Return(jen.Qual("fmt", "Errorf").Call(
+							jen.Lit("unsupported scan type for "+typ.Name+": %T"),
+							jen.Id("src"),
+						)),
+					),
+				),
+				jen.Line(),
+				jen.Id("m").Op(":=").Qual("github.com/jackc/pgx/v5/pgtype", "NewMap").Call(),
+				jen.Id("scanner").Op(":=").Qual("github.com/jackc/pgx/v5/pgtype", "NewCompositeTextScanner").Call(jen.Id("m"), jen.Id("buf")),
+			}, append(scanFields,
+				jen.Return(jen.Nil()),
+			)...)...,
+		).
+		Line()
+
+	file.Func().
+		Params(jen.Id("t").Id(typ.Name)).
+		Id("Value").
+		Params().
+		Params(jen.Qual("database/sql/driver", "Value"), jen.Error()).
+		Block(
+			append([]jen.Code{
+				jen.Id("builder").Op(":=").Qual("github.com/jackc/pgx/v5/pgtype", "NewCompositeTextBuilder").Call(
+					jen.Qual("github.com/jackc/pgx/v5/pgtype", "NewMap").Call(),
+					jen.Nil(),
+				),
+			}, append(valueFields,
+				jen.Line(),
+				jen.List(jen.Id("buf"), jen.Err()).Op(":=").Id("builder").Dot("Finish").Call(),
+				jen.If(jen.Err().Op("!=").Nil()).Block(
+					jen.Return(jen.Nil(), jen.Err()),
+				),
+				jen.Return(jen.String().Call(jen.Id("buf")), jen.Nil()),
+			)...)...,
+		).
+		Line()
+}
+
+func (p Printer) printDomainType(file *jen.File, typ engine.Type) {
+	file.Type().Id(typ.Name).Add(p.typeID(*typ.Base)).Line()
+
+	// A defined type does not inherit the methods of its base type, so
+	// any Scan or Value we generated for the base has to be forwarded.
+	if p.generatesScan(*typ.Base) {
+		file.Func().
+			Params(jen.Id("t").Op("*").Id(typ.Name)).
+			Id("Scan").
+			Params(jen.Id("src").Any()).
+			Error().
+			Block(
+				jen.Return(jen.Parens(jen.Op("*").Add(p.typeID(*typ.Base))).Call(jen.Id("t")).Dot("Scan").Call(jen.Id("src"))),
+			).
+			Line()
+	}
+
+	if p.generatesValue(*typ.Base) {
+		file.Func().
+			Params(jen.Id("t").Id(typ.Name)).
+			Id("Value").
+			Params().
+			Params(jen.Qual("database/sql/driver", "Value"), jen.Error()).
+			Block(
+				jen.Return(jen.Add(p.typeID(*typ.Base)).Call(jen.Id("t")).Dot("Value").Call()),
+			).
+			Line()
+	}
+}
+
+func (p Printer) generatesScan(typ engine.Type) bool {
+	if _, found := p.overrides[strcase.ToSnake(typ.Name)]; found {
+		return false
+	}
+
+	switch typ.Kind {
+	case engine.TypeKindEnum, engine.TypeKindComposite:
+		return true
+
+	case engine.TypeKindBase:
+		base, _ := p.baseType(typ.Name)
+		return base.scanner
+
+	case engine.TypeKindDomain:
+		return p.generatesScan(*typ.Base)
+
+	default:
+		return false
+	}
+}
+
+func (p Printer) generatesValue(typ engine.Type) bool {
+	if _, found := p.overrides[strcase.ToSnake(typ.Name)]; found {
+		return false
+	}
+
+	switch typ.Kind {
+	case engine.TypeKindComposite:
+		return true
+
+	case engine.TypeKindBase:
+		base, _ := p.baseType(typ.Name)
+		return base.scanner
+
+	case engine.TypeKindDomain:
+		return p.generatesValue(*typ.Base)
+
+	default:
+		return false
+	}
+}
+
+func (p Printer) rangeTypeID(typ engine.Type) jen.Code {
+	return jen.Qual("github.com/jackc/pgx/v5/pgtype", "Range").Index(p.typeID(*typ.Elem))
+}
+
+// needsArrayType reports whether arrays of the element type need a model
+// of their own. pgx has no codec for the array types of enums, composites
+// and domains, so these arrays are exchanged in their text representation.
+func (p Printer) needsArrayType(elem engine.Type) bool {
+	if _, found := p.overrides[strcase.ToSnake(elem.Name)]; found {
+		return false
+	}
+
+	switch elem.Kind {
+	case engine.TypeKindEnum, engine.TypeKindComposite, engine.TypeKindDomain:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// attributeTypeID returns the Go type of a composite attribute. Attributes
+// are exchanged in their text representation without a type OID, which pgx
+// cannot decode arrays from, so arrays always use an array model.
+func (p Printer) attributeTypeID(typ engine.Type) jen.Code {
+	if typ.Kind == engine.TypeKindArray {
+		return jen.Id(arrayTypeName(*typ.Elem))
+	}
+
+	return p.typeID(typ)
+}
+
+func arrayTypeName(elem engine.Type) string {
+	if elem.Nullable {
+		return "Nullable" + elem.Name + "Array"
+	}
+
+	return elem.Name + "Array"
+}
+
+func (p Printer) collectArrayTypes(queries engine.Result) []engine.Type {
+	arrayTypes := make(map[string]engine.Type)
+
+	var collect func(typ engine.Type, isAttribute bool)
+	collect = func(typ engine.Type, isAttribute bool) {
+		for _, attribute := range typ.Attributes {
+			collect(attribute.Type, true)
+		}
+
+		if typ.Base != nil {
+			collect(*typ.Base, false)
+		}
+
+		if typ.Elem != nil {
+			collect(*typ.Elem, false)
+
+			if typ.Kind == engine.TypeKindArray && (isAttribute || p.needsArrayType(*typ.Elem)) {
+				arrayTypes[arrayTypeName(*typ.Elem)] = typ
+			}
+		}
+	}
+
+	for _, typ := range queries.Types {
+		collect(typ, false)
+	}
+
+	for _, query := range queries.Queries {
+		for _, input := range query.Inputs {
+			collect(input.Type, false)
+		}
+
+		for _, output := range query.Outputs {
+			collect(output.Type, false)
+		}
+	}
+
+	// Sort the array types by name so that we have a stable order.
+	names := slices.Collect(maps.Keys(arrayTypes))
+	slices.SortStableFunc(names, cmp.Compare)
+
+	types := make([]engine.Type, len(names))
+	for idx, name := range names {
+		types[idx] = arrayTypes[name]
+	}
+
+	return types
+}
+
+func (p Printer) printArrayType(file *jen.File, typ engine.Type) {
+	typeName := arrayTypeName(*typ.Elem)
+
+	file.Type().Id(typeName).Index().Add(p.typeID(*typ.Elem)).Line()
+
+	// The array is decoded as an array of text, with each element then
+	// decoded on its own. Element OIDs are left as zero so that pgx picks
+	// a codec from the Go type.
+	file.Func().
+		Params(jen.Id("t").Op("*").Id(typeName)).
+		Id("Scan").
+		Params(jen.Id("src").Any()).
+		Error().
+		Block(
+			jen.Var().Id("buf").Index().Byte(),
+			jen.Switch(jen.Id("s").Op(":=").Id("src").Assert(jen.Type())).Block(
+				jen.Case(jen.Nil()).Block(
+					jen.Op("*").Id("t").Op("=").Nil(),
+					jen.Return(jen.Nil()),
+				),
+				jen.Case(jen.Index().Byte()).Block(
+					jen.Id("buf").Op("=").Id("s"),
+				),
+				jen.Case(jen.String()).Block(
+					jen.Id("buf").Op("=").Index().Byte().Call(jen.Id("s")),
+				),
+				jen.Default().Block(
+					jen.Return(jen.Qual("fmt", "Errorf").Call(
+						jen.Lit("unsupported scan type for "+typeName+": %T"),
+						jen.Id("src"),
+					)),
+				),
+			),
+			jen.Line(),
+			jen.Id("m").Op(":=").Qual("github.com/jackc/pgx/v5/pgtype", "NewMap").Call(),
+			jen.Line(),
+			jen.Var().Id("elems").Index().Op("*").String(),
+			jen.If(
+				jen.Err().Op(":=").Id("m").Dot("Scan").Call(
+					jen.Qual("github.com/jackc/pgx/v5/pgtype", "TextArrayOID"),
+					jen.Qual("github.com/jackc/pgx/v5/pgtype", "TextFormatCode"),
+					jen.Id("buf"),
+					jen.Op("&").Id("elems"),
+				),
+				jen.Err().Op("!=").Nil(),
+			).Block(
+				jen.Return(jen.Qual("fmt", "Errorf").Call(jen.Lit("scan "+typeName+": %w"), jen.Err())),
+			),
+			jen.Line(),
+			jen.Op("*").Id("t").Op("=").Make(jen.Id(typeName), jen.Len(jen.Id("elems"))),
+			jen.For(jen.List(jen.Id("idx"), jen.Id("elem")).Op(":=").Range().Id("elems")).Block(
+				jen.Var().Id("elemBuf").Index().Byte(),
+				jen.If(jen.Id("elem").Op("!=").Nil()).Block(
+					jen.Id("elemBuf").Op("=").Index().Byte().Call(jen.Op("*").Id("elem")),
+				),
+				jen.If(
+					jen.Err().Op(":=").Id("m").Dot("Scan").Call(
+						jen.Lit(0),
+						jen.Qual("github.com/jackc/pgx/v5/pgtype", "TextFormatCode"),
+						jen.Id("elemBuf"),
+						jen.Op("&").Parens(jen.Op("*").Id("t")).Index(jen.Id("idx")),
+					),
+					jen.Err().Op("!=").Nil(),
+				).Block(
+					jen.Return(jen.Qual("fmt", "Errorf").Call(jen.Lit("scan "+typeName+": %w"), jen.Err())),
+				),
+			),
+			jen.Return(jen.Nil()),
+		).
+		Line()
+
+	file.Func().
+		Params(jen.Id("t").Id(typeName)).
+		Id("Value").
+		Params().
+		Params(jen.Qual("database/sql/driver", "Value"), jen.Error()).
+		Block(
+			jen.If(jen.Id("t").Op("==").Nil()).Block(
+				jen.Return(jen.Nil(), jen.Nil()),
+			),
+			jen.Line(),
+			jen.Id("m").Op(":=").Qual("github.com/jackc/pgx/v5/pgtype", "NewMap").Call(),
+			jen.Line(),
+			jen.Id("elems").Op(":=").Make(jen.Index().Op("*").String(), jen.Len(jen.Id("t"))),
+			jen.For(jen.List(jen.Id("idx"), jen.Id("elem")).Op(":=").Range().Id("t")).Block(
+				jen.List(jen.Id("elemBuf"), jen.Err()).Op(":=").Id("m").Dot("Encode").Call(
+					jen.Lit(0),
+					jen.Qual("github.com/jackc/pgx/v5/pgtype", "TextFormatCode"),
+					jen.Id("elem"),
+					jen.Index().Byte().Values(),
+				),
+				jen.If(jen.Err().Op("!=").Nil()).Block(
+					jen.Return(jen.Nil(), jen.Err()),
+				),
+				jen.If(jen.Id("elemBuf").Op("!=").Nil()).Block(
+					jen.Id("elemText").Op(":=").String().Call(jen.Id("elemBuf")),
+					jen.Id("elems").Index(jen.Id("idx")).Op("=").Op("&").Id("elemText"),
+				),
+			),
+			jen.Line(),
+			jen.List(jen.Id("buf"), jen.Err()).Op(":=").Id("m").Dot("Encode").Call(
+				jen.Qual("github.com/jackc/pgx/v5/pgtype", "TextArrayOID"),
+				jen.Qual("github.com/jackc/pgx/v5/pgtype", "TextFormatCode"),
+				jen.Id("elems"),
+				jen.Nil(),
+			),
+			jen.If(jen.Err().Op("!=").Nil()).Block(
+				jen.Return(jen.Nil(), jen.Err()),
+			),
+			jen.Return(jen.String().Call(jen.Id("buf")), jen.Nil()),
+		).
+		Line()
+}
+
+// printTypedJSON prints the generic type that json and jsonb values with a
+// declared Go type are decoded into.
+func (p Printer) printTypedJSON(file *jen.File) {
+	file.Type().Id("TypedJSON").Types(jen.Id("T").Any()).Struct(
+		jen.Id("V").Id("T"),
+	)
+
+	file.Func().
+		Params(jen.Id("t").Op("*").Id("TypedJSON").Types(jen.Id("T"))).
+		Id("Scan").
+		Params(jen.Id("src").Any()).
+		Error().
+		Block(
+			jen.Var().Id("buf").Index().Byte(),
+			jen.Switch(jen.Id("src").Op(":=").Id("src").Assert(jen.Type())).Block(
+				jen.Case(jen.Index().Byte()).Block(
+					jen.Id("buf").Op("=").Id("src"),
+				),
+				jen.Case(jen.String()).Block(
+					jen.Id("buf").Op("=").Index().Byte().Call(jen.Id("src")),
+				),
+				jen.Default().Block(
+					jen.Return(jen.Qual("fmt", "Errorf").Call(jen.Lit("unsupported scan type for TypedJSON: %T"), jen.Id("src"))),
+				),
+			),
+			jen.Return(jen.Qual("encoding/json", "Unmarshal").Call(jen.Id("buf"), jen.Op("&").Id("t").Dot("V"))),
+		).
+		Line()
+
+	file.Func().
+		Params(jen.Id("t").Id("TypedJSON").Types(jen.Id("T"))).
+		Id("Value").
+		Params().
+		Params(jen.Qual("database/sql/driver", "Value"), jen.Error()).
+		Block(
+			jen.List(jen.Id("buf"), jen.Err()).Op(":=").Qual("encoding/json", "Marshal").Call(jen.Id("t").Dot("V")),
+			jen.If(jen.Err().Op("!=").Nil()).Block(
+				jen.Return(jen.Nil(), jen.Err()),
+			),
+			jen.Return(jen.String().Call(jen.Id("buf")), jen.Nil()),
+		).
+		Line()
+}
+
 func (p Printer) printNullableType(file *jen.File, typ engine.Type) {
 	file.Type().Id("Null"+typ.Name).Struct(
 		jen.Id(typ.Name).Id(typ.Name),
@@ -421,11 +1076,47 @@ func (p Printer) printNullableType(file *jen.File, typ engine.Type) {
 			jen.If(jen.Op("!").Id("t").Dot("Valid")).Block(
 				jen.Return(jen.Nil(), jen.Nil()),
 			),
-			jen.Return(jen.String().Call(jen.Id("t").Dot(typ.Name)), jen.Nil()),
+			jen.Return(p.nullableValue(typ)...),
 		)
 }
 
+func (p Printer) nullableValue(typ engine.Type) []jen.Code {
+	switch typ.Kind {
+	case engine.TypeKindComposite:
+		return []jen.Code{jen.Id("t").Dot(typ.Name).Dot("Value").Call()}
+
+	default:
+		return []jen.Code{jen.String().Call(jen.Id("t").Dot(typ.Name)), jen.Nil()}
+	}
+}
+
+// valueTypeID returns the Go type of a query input or output, which is the
+// declared json type when there is one.
+func (p Printer) valueTypeID(queryName, name, column string, typ engine.Type) jen.Code {
+	jsonType, found := p.jsonType(queryName, name, column)
+	if !found {
+		return p.typeID(typ)
+	}
+
+	typeID := jen.Id("TypedJSON").Index(jen.Qual(jsonType.GoPackage, jsonType.GoType))
+	if typ.Nullable {
+		return jen.Qual("database/sql", "Null").Index(typeID)
+	}
+
+	return typeID
+}
+
 func (p Printer) typeID(typ engine.Type) jen.Code {
+	// A nil slice already represents a null array, so arrays are never
+	// wrapped in sql.Null.
+	if typ.Kind == engine.TypeKindArray {
+		if p.needsArrayType(*typ.Elem) {
+			return jen.Id(arrayTypeName(*typ.Elem))
+		}
+
+		return jen.Index().Add(p.typeID(*typ.Elem))
+	}
+
 	typeID := jen.Id(typ.Name)
 
 	override, found := p.overrides[strcase.ToSnake(typ.Name)]
@@ -439,6 +1130,29 @@ func (p Printer) typeID(typ engine.Type) jen.Code {
 		}
 	}
 
+	// pgtype.Range and pgtype.Multirange already represent a null value,
+	// so they are never wrapped in sql.Null, and neither are base types
+	// that can represent one.
+	if !found || override.GoType == "" {
+		switch typ.Kind {
+		case engine.TypeKindRange:
+			return p.rangeTypeID(typ)
+
+		case engine.TypeKindMultirange:
+			return jen.Qual("github.com/jackc/pgx/v5/pgtype", "Multirange").Index(p.rangeTypeID(typ))
+
+		case engine.TypeKindBase:
+			base, _ := p.baseType(typ.Name)
+			if typ.Nullable && base.nullGoType != nil {
+				return base.nullGoType
+			}
+
+			if base.nullable {
+				return typeID
+			}
+		}
+	}
+
 	if typ.Nullable {
 		return jen.Qual("database/sql", "Null").Index(typeID)
 	}
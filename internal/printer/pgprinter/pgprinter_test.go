@@ -0,0 +1,1243 @@
+package pgprinter_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DanielleMaywood/otter/internal/engine"
+	"github.com/DanielleMaywood/otter/internal/printer"
+	"github.com/DanielleMaywood/otter/internal/printer/pgprinter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsupportedType(t *testing.T) {
+	t.Parallel()
+
+	citextType := engine.Type{
+		Kind: engine.TypeKindBase,
+		Name: "Citext",
+	}
+	int4Type := engine.Type{
+		Kind: engine.TypeKindBase,
+		Name: "Int4",
+	}
+
+	tests := []struct {
+		name        string
+		overrides   printer.TypeOverrides
+		query       engine.Query
+		expectedErr string
+	}{
+		{
+			name: "Output",
+			query: engine.Query{
+				Name: "GetUser",
+				Type: engine.QueryTypeOne,
+				Outputs: []engine.Output{
+					{Name: "ID", Type: int4Type},
+					{Name: "Email", Type: citextType},
+				},
+			},
+			expectedErr: "query 'GetUser' column 'Email': unsupported type 'citext', an override is needed for it",
+		},
+		{
+			name: "UnnamedOutput",
+			query: engine.Query{
+				Name: "GetUser",
+				Type: engine.QueryTypeOne,
+				Outputs: []engine.Output{
+					{Type: citextType},
+				},
+			},
+			expectedErr: "query 'GetUser' column 'Field0': unsupported type 'citext', an override is needed for it",
+		},
+		{
+			name: "Input",
+			query: engine.Query{
+				Name: "GetUserByEmail",
+				Type: engine.QueryTypeOne,
+				Inputs: []engine.Input{
+					{Name: "email", Type: citextType},
+				},
+				Outputs: []engine.Output{
+					{Name: "ID", Type: int4Type},
+				},
+			},
+			expectedErr: "query 'GetUserByEmail' parameter 'email': unsupported type 'citext', an override is needed for it",
+		},
+		{
+			name: "UnnamedInput",
+			query: engine.Query{
+				Name: "GetUserByEmail",
+				Type: engine.QueryTypeOne,
+				Inputs: []engine.Input{
+					{Type: citextType},
+				},
+				Outputs: []engine.Output{
+					{Name: "ID", Type: int4Type},
+				},
+			},
+			expectedErr: "query 'GetUserByEmail' parameter '$1': unsupported type 'citext', an override is needed for it",
+		},
+		{
+			name: "ArrayElement",
+			query: engine.Query{
+				Name: "GetEmails",
+				Type: engine.QueryTypeOne,
+				Outputs: []engine.Output{
+					{Name: "Emails", Type: engine.Type{Kind: engine.TypeKindArray, Name: "_citext", Elem: &citextType}},
+				},
+			},
+			expectedErr: "query 'GetEmails' column 'Emails': unsupported type 'citext', an override is needed for it",
+		},
+		{
+			name: "DomainBase",
+			query: engine.Query{
+				Name: "GetEmail",
+				Type: engine.QueryTypeOne,
+				Outputs: []engine.Output{
+					{Name: "Email", Type: engine.Type{Kind: engine.TypeKindDomain, Name: "Email", Base: &citextType}},
+				},
+			},
+			expectedErr: "query 'GetEmail' column 'Email': unsupported type 'citext', an override is needed for it",
+		},
+		{
+			name: "CompositeAttribute",
+			query: engine.Query{
+				Name: "GetContact",
+				Type: engine.QueryTypeOne,
+				Outputs: []engine.Output{
+					{
+						Name: "Contact",
+						Type: engine.Type{
+							Kind: engine.TypeKindComposite,
+							Name: "Contact",
+							Attributes: []engine.Attribute{
+								{Name: "Email", Type: citextType},
+							},
+						},
+					},
+				},
+			},
+			expectedErr: "query 'GetContact' column 'Contact': unsupported type 'citext', an override is needed for it",
+		},
+		{
+			name: "Overridden",
+			overrides: printer.TypeOverrides{
+				"citext": {GoType: "string"},
+			},
+			query: engine.Query{
+				Name: "GetUser",
+				Type: engine.QueryTypeOne,
+				Outputs: []engine.Output{
+					{Name: "Email", Type: citextType},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := pgprinter.New("queries", tt.overrides)
+
+			_, err := p.PrintQueries(engine.Result{
+				Types:   []engine.Type{citextType, int4Type},
+				Queries: map[string]engine.Query{tt.query.Name: tt.query},
+			})
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+// TestExecQueries checks that the methods of :execrows and :execresult
+// queries compile to the signatures they are documented to have.
+func TestExecQueries(t *testing.T) {
+	t.Parallel()
+
+	int4Type := engine.Type{Kind: engine.TypeKindBase, Name: "Int4"}
+	textType := engine.Type{Kind: engine.TypeKindBase, Name: "Text"}
+
+	p := pgprinter.New("main", nil)
+
+	printed, err := p.PrintQueries(engine.Result{
+		Types: []engine.Type{int4Type, textType},
+		Queries: map[string]engine.Query{
+			"DeleteUser": {
+				Name: "DeleteUser",
+				Type: engine.QueryTypeExecRows,
+				SQL:  "delete from users where id = $1",
+				Inputs: []engine.Input{
+					{Name: "id", Type: int4Type},
+				},
+			},
+			"RenameUser": {
+				Name: "RenameUser",
+				Type: engine.QueryTypeExecResult,
+				SQL:  "update users set name = $1 where id = $2",
+				Inputs: []engine.Input{
+					{Name: "name", Type: textType},
+					{Name: "id", Type: int4Type},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	runGenerated(t, printed, `
+		package main
+
+		import (
+			"context"
+
+			"github.com/jackc/pgx/v5/pgconn"
+		)
+
+		var (
+			_ func(*Querier, context.Context, Int4) (int64, error)                         = (*Querier).DeleteUser
+			_ func(*Querier, context.Context, RenameUserParams) (pgconn.CommandTag, error) = (*Querier).RenameUser
+		)
+
+		func main() {}
+	`)
+}
+
+func TestTimeTypeOptions(t *testing.T) {
+	t.Parallel()
+
+	timeTypes := []engine.Type{
+		{Kind: engine.TypeKindBase, Name: "Timestamptz"},
+		{Kind: engine.TypeKindBase, Name: "Timestamp"},
+		{Kind: engine.TypeKindBase, Name: "Date"},
+		{Kind: engine.TypeKindBase, Name: "Interval"},
+	}
+
+	tests := []struct {
+		name           string
+		typeOptions    printer.TypeOptions
+		expectedModels []string
+		program        string
+	}{
+		{
+			name: "Defaults",
+			expectedModels: []string{
+				"type Timestamptz = time.Time",
+				"type Timestamp = time.Time",
+				"type Date = time.Time",
+				"type Interval = pgtype.Interval",
+			},
+			program: `
+				package main
+
+				import (
+					"time"
+
+					"github.com/jackc/pgx/v5/pgtype"
+				)
+
+				func main() {
+					at := time.Date(2024, 2, 29, 12, 30, 0, 0, time.UTC)
+					expectEqual(roundTrip[Timestamptz](pgtype.TimestamptzOID, at), at)
+					expectEqual(roundTrip[Timestamp](pgtype.TimestampOID, at), at)
+					expectEqual(roundTrip[Date](pgtype.DateOID, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)), time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC))
+
+					interval := Interval{Months: 1, Days: 2, Microseconds: 3, Valid: true}
+					expectEqual(roundTrip[Interval](pgtype.IntervalOID, interval), interval)
+				}
+			`,
+		},
+		{
+			name:        "TimestamptzUTC",
+			typeOptions: printer.TypeOptions{Timestamptz: printer.TimestamptzTypeUTC},
+			expectedModels: []string{
+				"type Timestamptz struct {\n\ttime.Time\n}",
+			},
+			program: `
+				package main
+
+				import (
+					"time"
+
+					"github.com/jackc/pgx/v5/pgtype"
+				)
+
+				func main() {
+					at := time.Date(2024, 2, 29, 12, 30, 0, 0, time.FixedZone("CET", 60*60))
+
+					scanned := roundTrip[Timestamptz](pgtype.TimestamptzOID, Timestamptz{at})
+					expectEqual(scanned.Location(), time.UTC)
+					expectEqual(scanned.Time, at.UTC())
+
+					expectScanError[Timestamptz](pgtype.TimestamptzOID, pgtype.Timestamptz{InfinityModifier: pgtype.Infinity, Valid: true}, "infinity cannot be represented")
+				}
+			`,
+		},
+		{
+			name:        "TimestamptzPgtype",
+			typeOptions: printer.TypeOptions{Timestamptz: printer.TimestamptzTypePgtype},
+			expectedModels: []string{
+				"type Timestamptz = pgtype.Timestamptz",
+			},
+			program: `
+				package main
+
+				import (
+					"time"
+
+					"github.com/jackc/pgx/v5/pgtype"
+				)
+
+				func main() {
+					at := Timestamptz{Time: time.Date(2024, 2, 29, 12, 30, 0, 0, time.UTC), Valid: true}
+					scanned := roundTrip[Timestamptz](pgtype.TimestamptzOID, at)
+					expectEqual(scanned.Time, at.Time)
+					expectEqual(scanned.Valid, true)
+
+					infinity := Timestamptz{InfinityModifier: pgtype.NegativeInfinity, Valid: true}
+					expectEqual(roundTrip[Timestamptz](pgtype.TimestamptzOID, infinity), infinity)
+				}
+			`,
+		},
+		{
+			name:        "TimestampCivil",
+			typeOptions: printer.TypeOptions{Timestamp: printer.TimestampTypeCivil},
+			expectedModels: []string{
+				"type Timestamp struct {\n\tcivil.DateTime\n}",
+			},
+			program: `
+				package main
+
+				import (
+					"cloud.google.com/go/civil"
+					"github.com/jackc/pgx/v5/pgtype"
+				)
+
+				func main() {
+					at := Timestamp{civil.DateTime{
+						Date: civil.Date{Year: 2024, Month: 2, Day: 29},
+						Time: civil.Time{Hour: 12, Minute: 30},
+					}}
+					expectEqual(roundTrip[Timestamp](pgtype.TimestampOID, at), at)
+
+					expectScanError[Timestamp](pgtype.TimestampOID, pgtype.Timestamp{InfinityModifier: pgtype.Infinity, Valid: true}, "infinity cannot be represented")
+				}
+			`,
+		},
+		{
+			name:        "TimestampPgtype",
+			typeOptions: printer.TypeOptions{Timestamp: printer.TimestampTypePgtype},
+			expectedModels: []string{
+				"type Timestamp = pgtype.Timestamp",
+			},
+			program: `
+				package main
+
+				import (
+					"time"
+
+					"github.com/jackc/pgx/v5/pgtype"
+				)
+
+				func main() {
+					at := Timestamp{Time: time.Date(2024, 2, 29, 12, 30, 0, 0, time.UTC), Valid: true}
+					expectEqual(roundTrip[Timestamp](pgtype.TimestampOID, at), at)
+
+					infinity := Timestamp{InfinityModifier: pgtype.Infinity, Valid: true}
+					expectEqual(roundTrip[Timestamp](pgtype.TimestampOID, infinity), infinity)
+				}
+			`,
+		},
+		{
+			name:        "DateCivil",
+			typeOptions: printer.TypeOptions{Date: printer.DateTypeCivil},
+			expectedModels: []string{
+				"type Date struct {\n\tcivil.Date\n}",
+			},
+			program: `
+				package main
+
+				import (
+					"cloud.google.com/go/civil"
+					"github.com/jackc/pgx/v5/pgtype"
+				)
+
+				func main() {
+					date := Date{civil.Date{Year: 2024, Month: 2, Day: 29}}
+					expectEqual(roundTrip[Date](pgtype.DateOID, date), date)
+
+					expectScanError[Date](pgtype.DateOID, pgtype.Date{InfinityModifier: pgtype.NegativeInfinity, Valid: true}, "-infinity cannot be represented")
+				}
+			`,
+		},
+		{
+			name:        "DatePgtype",
+			typeOptions: printer.TypeOptions{Date: printer.DateTypePgtype},
+			expectedModels: []string{
+				"type Date = pgtype.Date",
+			},
+			program: `
+				package main
+
+				import (
+					"time"
+
+					"github.com/jackc/pgx/v5/pgtype"
+				)
+
+				func main() {
+					date := Date{Time: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), Valid: true}
+					expectEqual(roundTrip[Date](pgtype.DateOID, date), date)
+
+					infinity := Date{InfinityModifier: pgtype.Infinity, Valid: true}
+					expectEqual(roundTrip[Date](pgtype.DateOID, infinity), infinity)
+				}
+			`,
+		},
+		{
+			name:        "IntervalDuration",
+			typeOptions: printer.TypeOptions{Interval: printer.IntervalTypeDuration},
+			expectedModels: []string{
+				"type Interval struct {\n\ttime.Duration\n}",
+			},
+			program: `
+				package main
+
+				import (
+					"time"
+
+					"github.com/jackc/pgx/v5/pgtype"
+				)
+
+				func main() {
+					interval := Interval{26*time.Hour + 3*time.Second + 4*time.Microsecond}
+					expectEqual(roundTrip[Interval](pgtype.IntervalOID, interval), interval)
+
+					expectScanError[Interval](pgtype.IntervalOID, pgtype.Interval{Months: 1, Valid: true}, "an interval of months cannot be represented")
+				}
+			`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := pgprinter.New("main", nil, pgprinter.WithTypeOptions(tt.typeOptions))
+
+			printed, err := p.PrintQueries(engine.Result{Types: timeTypes})
+			require.NoError(t, err)
+
+			for _, expected := range tt.expectedModels {
+				assert.Contains(t, printed.Models, expected)
+			}
+
+			runGenerated(t, printed, tt.program)
+		})
+	}
+}
+
+func TestNumericTypeOptions(t *testing.T) {
+	t.Parallel()
+
+	numericType := engine.Type{Kind: engine.TypeKindBase, Name: "Numeric", Precision: 12, Scale: 2}
+
+	result := engine.Result{
+		Types: []engine.Type{numericType},
+		Queries: map[string]engine.Query{
+			"GetPrice": {
+				Name: "GetPrice",
+				Type: engine.QueryTypeOne,
+				Outputs: []engine.Output{
+					{Name: "Amount", Type: numericType},
+					{Name: "Discount", Type: nullable(numericType)},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		typeOptions     printer.TypeOptions
+		expectedModels  []string
+		expectedQueries []string
+		program         string
+	}{
+		{
+			name: "Defaults",
+			expectedModels: []string{
+				"type Numeric = pgtype.Numeric",
+			},
+			expectedQueries: []string{
+				"Amount   Numeric",
+				"Discount Numeric",
+			},
+			program: `
+				package main
+
+				import (
+					"math/big"
+
+					"github.com/jackc/pgx/v5/pgtype"
+				)
+
+				func main() {
+					amount := Numeric{Int: big.NewInt(1234), Exp: -2, Valid: true}
+					expectEqual(roundTrip[Numeric](pgtype.NumericOID, amount), amount)
+					expectEqual(roundTrip[Numeric](pgtype.NumericOID, Numeric{}), Numeric{})
+				}
+			`,
+		},
+		{
+			name:        "Decimal",
+			typeOptions: printer.TypeOptions{Numeric: printer.NumericTypeDecimal},
+			expectedModels: []string{
+				"type Numeric = decimal.Decimal",
+			},
+			expectedQueries: []string{
+				"Amount   Numeric",
+				"Discount decimal.NullDecimal",
+			},
+			program: `
+				package main
+
+				import (
+					"github.com/jackc/pgx/v5/pgtype"
+					"github.com/shopspring/decimal"
+				)
+
+				func main() {
+					amount := decimal.RequireFromString("12.34")
+					expectEqual(roundTrip[Numeric](pgtype.NumericOID, amount), amount)
+
+					discount := decimal.NullDecimal{Decimal: amount, Valid: true}
+					expectEqual(roundTrip[decimal.NullDecimal](pgtype.NumericOID, discount).Decimal, amount)
+					expectEqual(roundTrip[decimal.NullDecimal](pgtype.NumericOID, decimal.NullDecimal{}).Valid, false)
+				}
+			`,
+		},
+		{
+			name:        "BigRat",
+			typeOptions: printer.TypeOptions{Numeric: printer.NumericTypeBigRat},
+			expectedModels: []string{
+				"type Numeric big.Rat",
+			},
+			expectedQueries: []string{
+				"Amount   Numeric",
+				"Discount sql.Null[Numeric]",
+			},
+			program: `
+				package main
+
+				import (
+					"database/sql"
+					"math/big"
+					"strings"
+
+					"github.com/jackc/pgx/v5/pgtype"
+				)
+
+				func main() {
+					var amount Numeric
+					(*big.Rat)(&amount).SetString("12.34")
+
+					scanned := roundTrip[Numeric](pgtype.NumericOID, amount)
+					expectEqual((*big.Rat)(&scanned).RatString(), "617/50")
+					expectEqual((*big.Rat)(&amount).RatString(), "617/50")
+
+					discount := roundTrip[sql.Null[Numeric]](pgtype.NumericOID, sql.Null[Numeric]{V: amount, Valid: true})
+					expectEqual((*big.Rat)(&discount.V).RatString(), "617/50")
+					expectEqual(roundTrip[sql.Null[Numeric]](pgtype.NumericOID, sql.Null[Numeric]{}).Valid, false)
+
+					var third Numeric
+					(*big.Rat)(&third).SetFrac64(1, 3)
+					if _, err := third.Value(); err == nil || !strings.Contains(err.Error(), "1/3 has no exact decimal representation") {
+						fail("value 1/3: got error %v", err)
+					}
+				}
+			`,
+		},
+		{
+			name:        "String",
+			typeOptions: printer.TypeOptions{Numeric: printer.NumericTypeString},
+			expectedModels: []string{
+				"type Numeric = string",
+			},
+			expectedQueries: []string{
+				"Amount   Numeric",
+				"Discount sql.Null[Numeric]",
+			},
+			program: `
+				package main
+
+				import (
+					"math/big"
+
+					"github.com/jackc/pgx/v5/pgtype"
+				)
+
+				func main() {
+					// pgx always sends strings in the text format.
+					buf, err := typeMap.Encode(pgtype.NumericOID, pgtype.TextFormatCode, Numeric("12.34"), nil)
+					if err != nil {
+						fail("encode 12.34: %v", err)
+					}
+					expectEqual(string(buf), "12.34")
+
+					amount := pgtype.Numeric{Int: big.NewInt(1234), Exp: -2, Valid: true}
+					expectEqual(roundTrip[Numeric](pgtype.NumericOID, amount), "12.34")
+				}
+			`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := pgprinter.New("main", nil, pgprinter.WithTypeOptions(tt.typeOptions))
+
+			printed, err := p.PrintQueries(result)
+			require.NoError(t, err)
+
+			for _, expected := range tt.expectedModels {
+				assert.Contains(t, printed.Models, expected)
+			}
+
+			for _, expected := range tt.expectedQueries {
+				assert.Contains(t, printed.Queries, expected)
+			}
+
+			runGenerated(t, printed, tt.program)
+		})
+	}
+}
+
+func TestJSONTypes(t *testing.T) {
+	t.Parallel()
+
+	jsonbType := engine.Type{
+		Kind: engine.TypeKindBase,
+		Name: "Jsonb",
+	}
+	textType := engine.Type{
+		Kind: engine.TypeKindBase,
+		Name: "Text",
+	}
+
+	jsonTypes := printer.JSONTypes{
+		Columns: map[string]printer.JSONType{
+			"users.settings": {GoPackage: "net/url", GoType: "Values"},
+			"users.name":     {GoPackage: "net/url", GoType: "Values"},
+		},
+		Queries: map[string]printer.JSONType{
+			"GetUserHeaders.settings": {GoPackage: "net/http", GoType: "Header"},
+			"UpdateSettings.settings": {GoPackage: "net/url", GoType: "Values"},
+			"GetUserName.name":        {GoPackage: "net/url", GoType: "Values"},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		query           engine.Query
+		expectedQueries []string
+		expectedErr     string
+	}{
+		{
+			name: "Column",
+			query: engine.Query{
+				Name: "GetUserSettings",
+				Type: engine.QueryTypeOne,
+				Outputs: []engine.Output{
+					{Name: "Settings", Type: jsonbType, Column: "users.settings"},
+				},
+			},
+			expectedQueries: []string{
+				"(TypedJSON[url.Values], error)",
+			},
+		},
+		{
+			name: "NullableColumn",
+			query: engine.Query{
+				Name: "ListUserSettings",
+				Type: engine.QueryTypeMany,
+				Outputs: []engine.Output{
+					{Name: "Settings", Type: nullable(jsonbType), Column: "users.settings"},
+				},
+			},
+			expectedQueries: []string{
+				"([]sql.Null[TypedJSON[url.Values]], error)",
+			},
+		},
+		{
+			name: "QueryBeforeColumn",
+			query: engine.Query{
+				Name: "GetUserHeaders",
+				Type: engine.QueryTypeOne,
+				Outputs: []engine.Output{
+					{Name: "Settings", Type: jsonbType, Column: "users.settings"},
+				},
+			},
+			expectedQueries: []string{
+				"(TypedJSON[http.Header], error)",
+			},
+		},
+		{
+			name: "UndeclaredColumn",
+			query: engine.Query{
+				Name: "GetUserPreferences",
+				Type: engine.QueryTypeOne,
+				Outputs: []engine.Output{
+					{Name: "Preferences", Type: jsonbType, Column: "users.preferences"},
+				},
+			},
+			expectedQueries: []string{
+				"(Jsonb, error)",
+			},
+		},
+		{
+			name: "Parameter",
+			query: engine.Query{
+				Name: "UpdateSettings",
+				Type: engine.QueryTypeExec,
+				Inputs: []engine.Input{
+					{Name: "settings", Type: jsonbType},
+				},
+			},
+			expectedQueries: []string{
+				"UpdateSettings(ctx context.Context, settings TypedJSON[url.Values]) error",
+			},
+		},
+		{
+			name: "ColumnOfOtherType",
+			query: engine.Query{
+				Name: "GetUserByName",
+				Type: engine.QueryTypeOne,
+				Outputs: []engine.Output{
+					{Name: "Name", Type: textType, Column: "users.name"},
+				},
+			},
+			expectedErr: "query 'GetUserByName' column 'Name': a json type is declared for it but it has type 'text'",
+		},
+		{
+			name: "QueryOutputOfOtherType",
+			query: engine.Query{
+				Name: "GetUserName",
+				Type: engine.QueryTypeOne,
+				Outputs: []engine.Output{
+					{Name: "Name", Type: textType},
+				},
+			},
+			expectedErr: "query 'GetUserName' column 'Name': a json type is declared for it but it has type 'text'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := pgprinter.New("queries", nil, pgprinter.WithJSONTypes(jsonTypes))
+
+			printed, err := p.PrintQueries(engine.Result{
+				Types:   []engine.Type{jsonbType, textType},
+				Queries: map[string]engine.Query{tt.query.Name: tt.query},
+			})
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			for _, expected := range tt.expectedQueries {
+				assert.Contains(t, printed.Queries, expected)
+			}
+		})
+	}
+}
+
+func TestTypedJSON(t *testing.T) {
+	t.Parallel()
+
+	jsonType := engine.Type{Kind: engine.TypeKindBase, Name: "Json"}
+	jsonbType := engine.Type{Kind: engine.TypeKindBase, Name: "Jsonb"}
+
+	p := pgprinter.New("main", nil, pgprinter.WithJSONTypes(printer.JSONTypes{
+		Columns: map[string]printer.JSONType{
+			"users.settings": {GoPackage: "net/url", GoType: "Values"},
+		},
+	}))
+
+	printed, err := p.PrintQueries(engine.Result{
+		Types: []engine.Type{jsonType, jsonbType},
+		Queries: map[string]engine.Query{
+			"GetSettings": {
+				Name: "GetSettings",
+				Type: engine.QueryTypeOne,
+				Outputs: []engine.Output{
+					{Name: "Settings", Type: nullable(jsonbType), Column: "users.settings"},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	runGenerated(t, printed, `
+		package main
+
+		import (
+			"database/sql"
+			"net/url"
+
+			"github.com/jackc/pgx/v5/pgtype"
+		)
+
+		func main() {
+			settings := TypedJSON[url.Values]{V: url.Values{"theme": {"dark"}, "tags": {"a", "b"}}}
+			expectEqual(roundTrip[TypedJSON[url.Values]](pgtype.JSONOID, settings), settings)
+			expectEqual(roundTrip[TypedJSON[url.Values]](pgtype.JSONBOID, settings), settings)
+
+			valid := sql.Null[TypedJSON[url.Values]]{V: settings, Valid: true}
+			expectEqual(roundTrip[sql.Null[TypedJSON[url.Values]]](pgtype.JSONBOID, valid), valid)
+			expectEqual(roundTrip[sql.Null[TypedJSON[url.Values]]](pgtype.JSONBOID, nil), sql.Null[TypedJSON[url.Values]]{})
+
+			expectScanError[TypedJSON[url.Values]](pgtype.JSONBOID, "[1, 2]", "cannot unmarshal array")
+		}
+	`)
+}
+
+func TestCompositeTypes(t *testing.T) {
+	t.Parallel()
+
+	int4Type := engine.Type{Kind: engine.TypeKindBase, Name: "Int4"}
+	textType := engine.Type{Kind: engine.TypeKindBase, Name: "Text"}
+	addressType := engine.Type{
+		Kind: engine.TypeKindComposite,
+		Name: "Address",
+		Attributes: []engine.Attribute{
+			{Name: "Street", Type: textType},
+			{Name: "Number", Type: nullable(int4Type)},
+		},
+	}
+	personType := engine.Type{
+		Kind: engine.TypeKindComposite,
+		Name: "Person",
+		Attributes: []engine.Attribute{
+			{Name: "Name", Type: textType},
+			{Name: "Address", Type: nullable(addressType)},
+			{Name: "Nicknames", Type: nullable(engine.Type{Kind: engine.TypeKindArray, Name: "_text", Elem: ptr(nullable(textType))})},
+		},
+	}
+
+	p := pgprinter.New("main", nil)
+
+	printed, err := p.PrintQueries(engine.Result{
+		Types: []engine.Type{int4Type, textType, addressType, personType},
+		Queries: map[string]engine.Query{
+			"GetPerson": {
+				Name: "GetPerson",
+				Type: engine.QueryTypeOne,
+				Outputs: []engine.Output{
+					{Name: "Person", Type: nullable(personType)},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	runGenerated(t, printed, `
+		package main
+
+		import "database/sql"
+
+		func main() {
+			person := Person{
+				Name: "Ada",
+				Address: sql.Null[Address]{
+					V:     Address{Street: "1 \"Main\", St", Number: sql.Null[int32]{V: 12, Valid: true}},
+					Valid: true,
+				},
+				Nicknames: NullableTextArray{{V: "a", Valid: true}, {}},
+			}
+			expectEqual(scanText[Person](valueText(person)), person)
+			expectEqual(scanText[Person]("(Ada,\"(\"\"Main St\"\",12)\",\"{a,NULL}\")"), Person{
+				Name:      "Ada",
+				Address:   sql.Null[Address]{V: Address{Street: "Main St", Number: sql.Null[int32]{V: 12, Valid: true}}, Valid: true},
+				Nicknames: NullableTextArray{{V: "a", Valid: true}, {}},
+			})
+
+			// Postgres leaves a null attribute empty.
+			expectEqual(scanText[Person]("(Ada,,)"), Person{Name: "Ada"})
+			expectEqual(valueText(Person{Name: "Ada"}), "(Ada,,)")
+			expectEqual(scanText[Person]("(Ada,\"(Main,)\",{})"), Person{
+				Name:      "Ada",
+				Address:   sql.Null[Address]{V: Address{Street: "Main"}, Valid: true},
+				Nicknames: NullableTextArray{},
+			})
+
+			row := sql.Null[Person]{V: person, Valid: true}
+			expectEqual(scanText[sql.Null[Person]](valueText(row)), row)
+		}
+	`)
+}
+
+// TestCompositeTimeTypes checks the time type options within composites,
+// whose attributes pgx hands to the types' Scan methods as text.
+func TestCompositeTimeTypes(t *testing.T) {
+	t.Parallel()
+
+	timestamptzType := engine.Type{Kind: engine.TypeKindBase, Name: "Timestamptz"}
+	timestampType := engine.Type{Kind: engine.TypeKindBase, Name: "Timestamp"}
+	dateType := engine.Type{Kind: engine.TypeKindBase, Name: "Date"}
+	eventType := engine.Type{
+		Kind: engine.TypeKindComposite,
+		Name: "Event",
+		Attributes: []engine.Attribute{
+			{Name: "At", Type: timestamptzType},
+			{Name: "Local", Type: timestampType},
+			{Name: "On", Type: nullable(dateType)},
+		},
+	}
+
+	p := pgprinter.New("main", nil, pgprinter.WithTypeOptions(printer.TypeOptions{
+		Timestamptz: printer.TimestamptzTypeUTC,
+		Timestamp:   printer.TimestampTypeCivil,
+		Date:        printer.DateTypeCivil,
+	}))
+
+	printed, err := p.PrintQueries(engine.Result{
+		Types: []engine.Type{timestamptzType, timestampType, dateType, eventType},
+		Queries: map[string]engine.Query{
+			"GetEvent": {
+				Name: "GetEvent",
+				Type: engine.QueryTypeOne,
+				Outputs: []engine.Output{
+					{Name: "Event", Type: eventType},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	runGenerated(t, printed, `
+		package main
+
+		import (
+			"database/sql"
+			"time"
+
+			"cloud.google.com/go/civil"
+		)
+
+		func main() {
+			event := Event{
+				At: Timestamptz{time.Date(2024, 2, 29, 11, 30, 0, 0, time.UTC)},
+				Local: Timestamp{civil.DateTime{
+					Date: civil.Date{Year: 2024, Month: 2, Day: 29},
+					Time: civil.Time{Hour: 12, Minute: 30},
+				}},
+				On: sql.Null[Date]{V: Date{civil.Date{Year: 2024, Month: 2, Day: 29}}, Valid: true},
+			}
+			expectEqual(scanText[Event]("(\"2024-02-29 12:30:00+01\",\"2024-02-29 12:30:00\",2024-02-29)"), event)
+			expectEqual(scanText[Event]("(\"2024-02-29 11:30:00+00\",\"2024-02-29 12:30:00\",)"), Event{At: event.At, Local: event.Local})
+
+			expectScanTextError[Event]("(infinity,\"2024-02-29 12:30:00\",)", "infinity cannot be represented")
+			expectScanTextError[Event]("(\"2024-02-29 11:30:00+00\",-infinity,)", "-infinity cannot be represented")
+			expectScanTextError[Event]("(\"2024-02-29 11:30:00+00\",\"2024-02-29 12:30:00\",infinity)", "infinity cannot be represented")
+		}
+	`)
+}
+
+func TestArrayTypes(t *testing.T) {
+	t.Parallel()
+
+	int4Type := engine.Type{Kind: engine.TypeKindBase, Name: "Int4"}
+	textType := engine.Type{Kind: engine.TypeKindBase, Name: "Text"}
+	moodType := engine.Type{Kind: engine.TypeKindEnum, Name: "Mood", Variants: []string{"happy", "sad"}}
+	scoreType := engine.Type{Kind: engine.TypeKindDomain, Name: "Score", Base: &int4Type}
+	addressType := engine.Type{
+		Kind: engine.TypeKindComposite,
+		Name: "Address",
+		Attributes: []engine.Attribute{
+			{Name: "Street", Type: textType},
+			{Name: "Number", Type: nullable(int4Type)},
+		},
+	}
+
+	arrayOf := func(elem engine.Type) engine.Type {
+		return engine.Type{Kind: engine.TypeKindArray, Name: "_" + elem.Name, Elem: &elem}
+	}
+
+	p := pgprinter.New("main", nil)
+
+	printed, err := p.PrintQueries(engine.Result{
+		Types: []engine.Type{int4Type, textType, moodType, scoreType, addressType},
+		Queries: map[string]engine.Query{
+			"GetArrays": {
+				Name: "GetArrays",
+				Type: engine.QueryTypeOne,
+				Outputs: []engine.Output{
+					{Name: "IDs", Type: arrayOf(nullable(int4Type))},
+					{Name: "Moods", Type: arrayOf(nullable(moodType))},
+					{Name: "Scores", Type: arrayOf(nullable(scoreType))},
+					{Name: "Addresses", Type: arrayOf(addressType)},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	runGenerated(t, printed, `
+		package main
+
+		import (
+			"database/sql"
+
+			"github.com/jackc/pgx/v5/pgtype"
+		)
+
+		func main() {
+			row := GetArraysRow{
+				IDs:       []sql.Null[Int4]{{V: 1, Valid: true}, {}},
+				Moods:     NullableMoodArray{{V: MoodHappy, Valid: true}, {}},
+				Scores:    NullableScoreArray{{V: 5, Valid: true}, {}},
+				Addresses: AddressArray{{Street: "Main St", Number: sql.Null[int32]{V: 12, Valid: true}}, {Street: "High St"}},
+			}
+
+			expectEqual(roundTrip[[]sql.Null[Int4]](pgtype.Int4ArrayOID, row.IDs), row.IDs)
+
+			expectEqual(scanText[NullableMoodArray](valueText(row.Moods)), row.Moods)
+			expectEqual(scanText[NullableMoodArray]("{happy,NULL}"), row.Moods)
+
+			expectEqual(scanText[NullableScoreArray](valueText(row.Scores)), row.Scores)
+			expectEqual(scanText[NullableScoreArray]("{5,NULL}"), row.Scores)
+
+			expectEqual(scanText[AddressArray](valueText(row.Addresses)), row.Addresses)
+			expectEqual(scanText[AddressArray]("{\"(\\\"Main St\\\",12)\",\"(\\\"High St\\\",)\"}"), row.Addresses)
+
+			expectEqual(scanText[NullableMoodArray]("{}"), NullableMoodArray{})
+		}
+	`)
+}
+
+func TestRangeTypes(t *testing.T) {
+	t.Parallel()
+
+	int8Type := engine.Type{Kind: engine.TypeKindBase, Name: "Int8"}
+	dateType := engine.Type{Kind: engine.TypeKindBase, Name: "Date"}
+
+	p := pgprinter.New("main", nil)
+
+	printed, err := p.PrintQueries(engine.Result{
+		Types: []engine.Type{int8Type, dateType},
+		Queries: map[string]engine.Query{
+			"GetBooking": {
+				Name: "GetBooking",
+				Type: engine.QueryTypeOne,
+				Outputs: []engine.Output{
+					{Name: "Stay", Type: engine.Type{Kind: engine.TypeKindRange, Name: "Daterange", Elem: &dateType}},
+					{Name: "Cancelled", Type: nullable(engine.Type{Kind: engine.TypeKindRange, Name: "Daterange", Elem: &dateType})},
+					{Name: "Seats", Type: engine.Type{Kind: engine.TypeKindMultirange, Name: "Int8multirange", Elem: &int8Type}},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	runGenerated(t, printed, `
+		package main
+
+		import (
+			"time"
+
+			"github.com/jackc/pgx/v5/pgtype"
+		)
+
+		func main() {
+			row := GetBookingRow{
+				Stay: pgtype.Range[Date]{
+					Lower:     time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC),
+					Upper:     time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+					LowerType: pgtype.Inclusive,
+					UpperType: pgtype.Exclusive,
+					Valid:     true,
+				},
+				Seats: pgtype.Multirange[pgtype.Range[Int8]]{
+					{Lower: 1, Upper: 3, LowerType: pgtype.Inclusive, UpperType: pgtype.Exclusive, Valid: true},
+					{Lower: 10, LowerType: pgtype.Inclusive, UpperType: pgtype.Unbounded, Valid: true},
+				},
+			}
+
+			expectEqual(roundTrip[pgtype.Range[Date]](pgtype.DaterangeOID, row.Stay), row.Stay)
+			expectEqual(roundTrip[pgtype.Range[Date]](pgtype.DaterangeOID, row.Cancelled), row.Cancelled)
+			expectEqual(roundTrip[pgtype.Multirange[pgtype.Range[Int8]]](pgtype.Int8multirangeOID, row.Seats), row.Seats)
+
+			empty := pgtype.Range[Date]{LowerType: pgtype.Empty, UpperType: pgtype.Empty, Valid: true}
+			expectEqual(roundTrip[pgtype.Range[Date]](pgtype.DaterangeOID, empty), empty)
+		}
+	`)
+}
+
+// roundTripHelpers are compiled alongside the generated code, to send
+// values through pgx in both the text and binary formats and scan them
+// back, as happens when they are sent to and read from the database.
+const roundTripHelpers = `
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var typeMap = pgtype.NewMap()
+
+func roundTrip[T any](oid uint32, value any) T {
+	var scanned T
+	for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+		buf, err := typeMap.Encode(oid, format, value, nil)
+		if err != nil {
+			fail("encode %v in format %d: %v", value, format, err)
+		}
+
+		if err := typeMap.Scan(oid, format, buf, &scanned); err != nil {
+			fail("scan %v in format %d: %v", value, format, err)
+		}
+	}
+
+	return scanned
+}
+
+// scanText scans the text representation of a value, as pgx does for the
+// types it has no codec for, such as composites, enums and their arrays.
+func scanText[T any](text string) T {
+	var scanned T
+	if err := typeMap.Scan(0, pgtype.TextFormatCode, []byte(text), &scanned); err != nil {
+		fail("scan %q: %v", text, err)
+	}
+
+	return scanned
+}
+
+// valueText returns the text representation that a value is sent as.
+func valueText(value any) string {
+	buf, err := typeMap.Encode(0, pgtype.TextFormatCode, value, nil)
+	if err != nil {
+		fail("encode %v: %v", value, err)
+	}
+
+	return string(buf)
+}
+
+func expectScanError[T any](oid uint32, value any, expected string) {
+	for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+		buf, err := typeMap.Encode(oid, format, value, nil)
+		if err != nil {
+			fail("encode %v in format %d: %v", value, format, err)
+		}
+
+		var scanned T
+		if err := typeMap.Scan(oid, format, buf, &scanned); err == nil || !strings.Contains(err.Error(), expected) {
+			fail("scan %v in format %d: got error %v, want one containing %q", value, format, err, expected)
+		}
+	}
+}
+
+func expectScanTextError[T any](text string, expected string) {
+	var scanned T
+	if err := typeMap.Scan(0, pgtype.TextFormatCode, []byte(text), &scanned); err == nil || !strings.Contains(err.Error(), expected) {
+		fail("scan %q: got error %v, want one containing %q", text, err, expected)
+	}
+}
+
+// expectEqual compares times with their Equal method, as pgx scans them
+// in the local time zone.
+func expectEqual[T any](got, expected T) {
+	equal := reflect.DeepEqual(got, expected)
+	if equaler, ok := any(got).(interface{ Equal(T) bool }); ok {
+		equal = equaler.Equal(expected)
+	}
+
+	if !equal {
+		fail("got %v, want %v", got, expected)
+	}
+}
+
+func fail(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
+`
+
+// generatedRequirements are the modules that the type options generate
+// code for. Generated code is the only user of them, so otter does not
+// require them.
+var generatedRequirements = []string{
+	"cloud.google.com/go v0.107.0",
+	"github.com/shopspring/decimal v1.2.0",
+}
+
+var generatedSums = []string{
+	"cloud.google.com/go v0.107.0 h1:qkj22L7bgkl6vIeZDlOY2po43Mx/TIa2Wsa7VR+PEww=",
+	"cloud.google.com/go v0.107.0/go.mod h1:wpc2eNrD7hXUTy8EKS10jkxpZBjASrORK7goS+3YX2I=",
+	"github.com/shopspring/decimal v1.2.0 h1:abSATXmQEYyShuxI4/vyW3tV1MrKAJzCZ/0zLUXYbsQ=",
+	"github.com/shopspring/decimal v1.2.0/go.mod h1:DKyhrW/HYNuLGql+MJL6WCR6knT2jwCFRcu2hWCYk4o=",
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func nullable(typ engine.Type) engine.Type {
+	typ.Nullable = true
+	return typ
+}
+
+// runGenerated builds the printed code with the given main package in a
+// module with the same requirements as otter, and runs it.
+func runGenerated(t *testing.T, printed printer.Result, program string) {
+	t.Helper()
+
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is needed to run the generated code")
+	}
+
+	goMod, err := os.ReadFile("../../../go.mod")
+	require.NoError(t, err)
+
+	goSum, err := os.ReadFile("../../../go.sum")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":      strings.Replace(string(goMod), "module github.com/DanielleMaywood/otter", "module roundtrip", 1) + "\nrequire (\n\t" + strings.Join(generatedRequirements, "\n\t") + "\n)\n",
+		"go.sum":      string(goSum) + strings.Join(generatedSums, "\n") + "\n",
+		"database.go": printed.Database,
+		"queries.go":  printed.Queries,
+		"models.go":   printed.Models,
+		"helpers.go":  roundTripHelpers,
+		"main.go":     program,
+	}
+	for name, contents := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644))
+	}
+
+	cmd := exec.CommandContext(t.Context(), goBin, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=readonly")
+
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
@@ -1,6 +1,11 @@
 package printer
 
-import "github.com/DanielleMaywood/otter/internal/engine"
+import (
+	"fmt"
+	"slices"
+
+	"github.com/DanielleMaywood/otter/internal/engine"
+)
 
 type TypeOverride struct {
 	GoPackage string `toml:"go_package"`
@@ -16,6 +21,121 @@ type NullOverride struct {
 
 type TypeOverrides map[string]TypeOverride
 
+// JSONType is a Go type that json and jsonb values are decoded into.
+type JSONType struct {
+	GoPackage string `toml:"go_package"`
+	GoType    string `toml:"go_type"`
+}
+
+// JSONTypes declare the Go types that json and jsonb values are decoded
+// into. Columns are keyed by `table.column`, and the inputs and outputs of
+// a query by `Query.name`, which take precedence over their column.
+type JSONTypes struct {
+	Columns map[string]JSONType `toml:"columns"`
+	Queries map[string]JSONType `toml:"queries"`
+}
+
+// TypeOptions choose between the Go types that built-in types can be
+// generated as. Infinite dates and timestamps can only be represented by
+// the pgtype types, the other types fail to scan them.
+type TypeOptions struct {
+	Numeric     NumericType     `toml:"numeric"`
+	Timestamptz TimestamptzType `toml:"timestamptz"`
+	Timestamp   TimestampType   `toml:"timestamp"`
+	Date        DateType        `toml:"date"`
+	Interval    IntervalType    `toml:"interval"`
+}
+
+// NumericType is the Go type that numeric values are generated as.
+type NumericType string
+
+var (
+	NumericTypePgtype  NumericType = "pgtype.Numeric"
+	NumericTypeDecimal NumericType = "shopspring/decimal"
+	NumericTypeBigRat  NumericType = "big.Rat"
+	NumericTypeString  NumericType = "string"
+)
+
+func (t *NumericType) UnmarshalText(text []byte) error {
+	return unmarshalTypeOption(t, "numeric", text,
+		NumericTypePgtype, NumericTypeDecimal, NumericTypeBigRat, NumericTypeString,
+	)
+}
+
+// TimestamptzType is the Go type that timestamptz values are generated as.
+// TimestamptzTypeUTC is a time.Time that is always in UTC, rather than in
+// the local time zone.
+type TimestamptzType string
+
+var (
+	TimestamptzTypeTime   TimestamptzType = "time.Time"
+	TimestamptzTypeUTC    TimestamptzType = "utc"
+	TimestamptzTypePgtype TimestamptzType = "pgtype.Timestamptz"
+)
+
+func (t *TimestamptzType) UnmarshalText(text []byte) error {
+	return unmarshalTypeOption(t, "timestamptz", text,
+		TimestamptzTypeTime, TimestamptzTypeUTC, TimestamptzTypePgtype,
+	)
+}
+
+// TimestampType is the Go type that timestamp values are generated as.
+// TimestampTypeCivil keeps them apart from timestamptz values, as they
+// are a date and a time of day without a time zone.
+type TimestampType string
+
+var (
+	TimestampTypeTime   TimestampType = "time.Time"
+	TimestampTypeCivil  TimestampType = "civil.DateTime"
+	TimestampTypePgtype TimestampType = "pgtype.Timestamp"
+)
+
+func (t *TimestampType) UnmarshalText(text []byte) error {
+	return unmarshalTypeOption(t, "timestamp", text,
+		TimestampTypeTime, TimestampTypeCivil, TimestampTypePgtype,
+	)
+}
+
+// DateType is the Go type that date values are generated as.
+type DateType string
+
+var (
+	DateTypeTime   DateType = "time.Time"
+	DateTypeCivil  DateType = "civil.Date"
+	DateTypePgtype DateType = "pgtype.Date"
+)
+
+func (t *DateType) UnmarshalText(text []byte) error {
+	return unmarshalTypeOption(t, "date", text,
+		DateTypeTime, DateTypeCivil, DateTypePgtype,
+	)
+}
+
+// IntervalType is the Go type that interval values are generated as.
+// Intervals of months cannot be scanned into a time.Duration, as months
+// vary in length.
+type IntervalType string
+
+var (
+	IntervalTypePgtype   IntervalType = "pgtype.Interval"
+	IntervalTypeDuration IntervalType = "time.Duration"
+)
+
+func (t *IntervalType) UnmarshalText(text []byte) error {
+	return unmarshalTypeOption(t, "interval", text,
+		IntervalTypePgtype, IntervalTypeDuration,
+	)
+}
+
+func unmarshalTypeOption[T ~string](option *T, typeName string, text []byte, options ...T) error {
+	if !slices.Contains(options, T(text)) {
+		return fmt.Errorf("unknown %s type '%s'", typeName, text)
+	}
+
+	*option = T(text)
+	return nil
+}
+
 type Result struct {
 	Database string
 	Models   string
@@ -23,5 +143,5 @@ type Result struct {
 }
 
 type Printer interface {
-	PrintQueries(queries engine.Result) Result
+	PrintQueries(queries engine.Result) (Result, error)
 }
@@ -27,9 +27,7 @@ func NewTypeNameTransformer(caser StringCaser) TypeNameTransformer {
 
 func (t TypeNameTransformer) Transform(result *engine.Result) {
 	for idx, typ := range result.Types {
-		typ.Name = t.caser.ToPascalCase(typ.Name)
-
-		result.Types[idx] = typ
+		result.Types[idx] = t.transformType(typ)
 	}
 
 	for queryName, query := range result.Queries {
@@ -40,14 +38,14 @@ func (t TypeNameTransformer) Transform(result *engine.Result) {
 				input.Name = t.caser.ToPascalCase(input.Name)
 			}
 
-			input.Type.Name = t.caser.ToPascalCase(input.Type.Name)
+			input.Type = t.transformType(input.Type)
 
 			query.Inputs[idx] = input
 		}
 
 		for idx, output := range query.Outputs {
 			output.Name = t.caser.ToPascalCase(output.Name)
-			output.Type.Name = t.caser.ToPascalCase(output.Type.Name)
+			output.Type = t.transformType(output.Type)
 
 			query.Outputs[idx] = output
 		}
@@ -55,3 +53,30 @@ func (t TypeNameTransformer) Transform(result *engine.Result) {
 		result.Queries[queryName] = query
 	}
 }
+
+func (t TypeNameTransformer) transformType(typ engine.Type) engine.Type {
+	typ.Name = t.caser.ToPascalCase(typ.Name)
+
+	if typ.Attributes != nil {
+		attributes := make([]engine.Attribute, len(typ.Attributes))
+		for idx, attribute := range typ.Attributes {
+			attributes[idx] = engine.Attribute{
+				Name: t.caser.ToPascalCase(attribute.Name),
+				Type: t.transformType(attribute.Type),
+			}
+		}
+		typ.Attributes = attributes
+	}
+
+	if typ.Base != nil {
+		base := t.transformType(*typ.Base)
+		typ.Base = &base
+	}
+
+	if typ.Elem != nil {
+		elem := t.transformType(*typ.Elem)
+		typ.Elem = &elem
+	}
+
+	return typ
+}
@@ -76,6 +76,33 @@ func TestTypeNameTransformer(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "CompositeAttributes",
+			before: engine.Result{
+				Types: []engine.Type{
+					{
+						Kind: engine.TypeKindComposite,
+						Name: "user_address",
+						Attributes: []engine.Attribute{
+							{Name: "street_id", Type: engine.Type{Name: "int4"}},
+						},
+					},
+				},
+				Queries: map[string]engine.Query{},
+			},
+			after: engine.Result{
+				Types: []engine.Type{
+					{
+						Kind: engine.TypeKindComposite,
+						Name: "UserAddress",
+						Attributes: []engine.Attribute{
+							{Name: "StreetID", Type: engine.Type{Name: "Int4"}},
+						},
+					},
+				},
+				Queries: map[string]engine.Query{},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -3,8 +3,13 @@ package otter
 import (
 	"context"
 	"fmt"
+	"io"
+	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/DanielleMaywood/otter/internal/engine"
 	"github.com/DanielleMaywood/otter/internal/printer"
@@ -18,6 +23,7 @@ type Otter struct {
 	engine  engine.Engine
 	printer printer.Printer
 	fs      afero.Fs
+	stderr  io.Writer
 
 	initialisms map[string]string
 
@@ -38,6 +44,14 @@ func WithFS(fs afero.Fs) Option {
 	}
 }
 
+// WithStderr sets where warnings about the queries are written to. It
+// defaults to os.Stderr.
+func WithStderr(stderr io.Writer) Option {
+	return func(o *Otter) {
+		o.stderr = stderr
+	}
+}
+
 func New(engine engine.Engine, printer printer.Printer, opts ...Option) Otter {
 	otter := Otter{
 		engine:       engine,
@@ -52,6 +66,9 @@ func New(engine engine.Engine, printer printer.Printer, opts ...Option) Otter {
 	if otter.fs == nil {
 		otter.fs = afero.NewOsFs()
 	}
+	if otter.stderr == nil {
+		otter.stderr = os.Stderr
+	}
 	return otter
 }
 
@@ -66,11 +83,19 @@ func (o Otter) Run(ctx context.Context, queryPath, outPath string) error {
 		return fmt.Errorf("resolve queries: %w", err)
 	}
 
+	for _, warning := range queries.Warnings {
+		fmt.Fprintf(o.stderr, "warning: %s\n", warning)
+	}
+
 	transformer.Transform(&queries,
 		transformer.NewTypeNameTransformer(transformer.NewStringCaser(o.initialisms)),
 	)
 
-	printed := o.printer.PrintQueries(queries)
+	printed, err := o.printer.PrintQueries(queries)
+	if err != nil {
+		return fmt.Errorf("print queries: %w", err)
+	}
+
 	if err := o.writePrintedQueries(outPath, printed); err != nil {
 		return fmt.Errorf("write queries: %w", err)
 	}
@@ -78,35 +103,108 @@ func (o Otter) Run(ctx context.Context, queryPath, outPath string) error {
 	return nil
 }
 
-func (o Otter) collectQueries(queryPath string) (map[string]string, error) {
-	entries, err := afero.ReadDir(o.fs, queryPath)
-	if err != nil {
-		return nil, fmt.Errorf("read directory '%s': %w", queryPath, err)
-	}
+// collectQueries walks the queries directory for query files. Queries in
+// subdirectories are namespaced by prefixing their name with the path to
+// them, so that `users/get_user.sql` becomes `UsersGetUser`. This keeps
+// queries with the same name in different directories apart. Queries at
+// the top of the directory keep their name as it is.
+func (o Otter) collectQueries(queryPath string) (map[string]engine.QuerySource, error) {
+	queryMap := make(map[string]engine.QuerySource)
 
-	queryMap := make(map[string]string)
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
+	err := afero.Walk(o.fs, queryPath, func(queryFile string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
 		}
 
-		queryName := entry.Name()
-		queryName, isQuery := strings.CutSuffix(queryName, ".sql")
+		if info.IsDir() {
+			return nil
+		}
+
+		queryName, isQuery := strings.CutSuffix(info.Name(), ".sql")
 		if !isQuery {
-			continue
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(queryPath, filepath.Dir(queryFile))
+		if err != nil {
+			return fmt.Errorf("relative path of '%s': %w", queryFile, err)
 		}
 
-		query, err := afero.ReadFile(o.fs, filepath.Join(queryPath, entry.Name()))
+		var namespace strings.Builder
+		if relativePath != "." {
+			for directory := range strings.SplitSeq(filepath.ToSlash(relativePath), "/") {
+				namespace.WriteString(o.goName(directory))
+			}
+		}
+
+		contents, err := afero.ReadFile(o.fs, queryFile)
 		if err != nil {
-			return nil, fmt.Errorf("read query '%s': %w", queryName, err)
+			return fmt.Errorf("read query '%s': %w", queryName, err)
+		}
+
+		// A file either holds a single query named after the file, or
+		// several queries named by their `-- name:` directives. Headers
+		// are checked here as this is the only place that knows which
+		// file a query came from.
+		fileQueries, err := engine.SplitQueryFile(queryFile, string(contents))
+		if err != nil {
+			return fmt.Errorf("parse query file '%s': %w", queryFile, err)
+		}
+
+		for _, fileQuery := range fileQueries {
+			name := fileQuery.Name
+			if name == "" {
+				name = queryName
+			}
+
+			if namespace.Len() > 0 {
+				name = namespace.String() + o.goName(name)
+			}
+
+			if _, found := queryMap[name]; found {
+				return fmt.Errorf("duplicate query '%s' in '%s'", name, queryFile)
+			}
+
+			// The lines trimmed from the start of the query are counted so
+			// that its header is still reported against the right line.
+			trimmed := strings.TrimLeftFunc(fileQuery.SQL, unicode.IsSpace)
+			queryMap[name] = engine.QuerySource{
+				SQL:  strings.TrimSpace(trimmed),
+				File: queryFile,
+				Line: fileQuery.Line + strings.Count(fileQuery.SQL[:len(fileQuery.SQL)-len(trimmed)], "\n"),
+			}
 		}
 
-		queryMap[queryName] = strings.TrimSpace(string(query))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk directory '%s': %w", queryPath, err)
 	}
 
 	return queryMap, nil
 }
 
+// goName converts a segment of a query's path, such as `get_user` or
+// `user-settings`, to the part of a Go name it stands for. Unlike the
+// type names, the segments keep their case so that `GetUser` is left
+// alone.
+func (o Otter) goName(segment string) string {
+	var sb strings.Builder
+
+	for _, part := range strings.FieldsFunc(segment, func(r rune) bool { return r == '_' || r == '-' }) {
+		if initialism, found := o.initialisms[part]; found {
+			sb.WriteString(initialism)
+			continue
+		}
+
+		first, size := utf8.DecodeRuneInString(part)
+		sb.WriteRune(unicode.ToUpper(first))
+		sb.WriteString(part[size:])
+	}
+
+	return sb.String()
+}
+
 func (o Otter) writePrintedQueries(outPath string, printed printer.Result) error {
 	databasePath := filepath.Join(outPath, o.databasePath)
 	queriesPath := filepath.Join(outPath, o.queriesPath)
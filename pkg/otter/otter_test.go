@@ -0,0 +1,164 @@
+package otter_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/DanielleMaywood/otter/internal/engine"
+	"github.com/DanielleMaywood/otter/internal/printer"
+	"github.com/DanielleMaywood/otter/pkg/otter"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingEngine struct {
+	queries map[string]engine.QuerySource
+}
+
+func (e *recordingEngine) ResolveQueries(_ context.Context, queries map[string]engine.QuerySource) (engine.Result, error) {
+	e.queries = queries
+	return engine.Result{}, nil
+}
+
+type emptyPrinter struct{}
+
+func (emptyPrinter) PrintQueries(engine.Result) (printer.Result, error) {
+	return printer.Result{}, nil
+}
+
+func TestCollectQueries(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		initialisms map[string]string
+		files       map[string]string
+		expected    map[string]string
+		// expectedPositions are the files and lines that queries are
+		// expected to start at, when checked.
+		expectedPositions map[string]string
+		expectedErr       string
+	}{
+		{
+			name: "TopLevel",
+			files: map[string]string{
+				"queries/GetUser.sql":    "select 1",
+				"queries/list_users.sql": "select 2",
+				"queries/admins.sql":     "-- name: list_admins :many\nselect 3;",
+				"queries/README.md":      "not a query",
+			},
+			expected: map[string]string{
+				"GetUser":     "select 1",
+				"list_users":  "select 2",
+				"list_admins": "-- name: list_admins :many\nselect 3;",
+			},
+		},
+		{
+			name: "NestedDirectories",
+			files: map[string]string{
+				"queries/users/get_user.sql":                "select 1",
+				"queries/users/admin-tools/list_admins.sql": "select 2",
+				"queries/posts/GetPost.sql":                 "select 3",
+			},
+			expected: map[string]string{
+				"UsersGetUser":              "select 1",
+				"UsersAdminToolsListAdmins": "select 2",
+				"PostsGetPost":              "select 3",
+			},
+		},
+		{
+			name:        "Initialisms",
+			initialisms: map[string]string{"id": "ID"},
+			files: map[string]string{
+				"queries/users/get_user_by_id.sql": "select 1",
+			},
+			expected: map[string]string{
+				"UsersGetUserByID": "select 1",
+			},
+		},
+		{
+			name: "NamedQueries",
+			files: map[string]string{
+				"queries/users/queries.sql": "-- name: GetUser :one\nselect 1;\n\n-- name: list_users :many\nselect 2;",
+			},
+			expected: map[string]string{
+				"UsersGetUser":   "-- name: GetUser :one\nselect 1;",
+				"UsersListUsers": "-- name: list_users :many\nselect 2;",
+			},
+		},
+		{
+			name: "QueryLines",
+			files: map[string]string{
+				"queries/GetUser.sql":     "\n\n-- :one\nselect 1",
+				"queries/users/users.sql": "-- Queries for users.\n\n-- name: GetUser :one\nselect 1;\n\n-- name: ListUsers :many\n\nselect 2;",
+			},
+			expected: map[string]string{
+				"GetUser":        "-- :one\nselect 1",
+				"UsersGetUser":   "-- name: GetUser :one\nselect 1;",
+				"UsersListUsers": "-- name: ListUsers :many\n\nselect 2;",
+			},
+			expectedPositions: map[string]string{
+				"GetUser":        "queries/GetUser.sql:3",
+				"UsersGetUser":   "queries/users/users.sql:3",
+				"UsersListUsers": "queries/users/users.sql:6",
+			},
+		},
+		{
+			name: "CollidingNamespaces",
+			files: map[string]string{
+				"queries/users/get_user.sql": "select 1",
+				"queries/UsersGetUser.sql":   "select 2",
+			},
+			expectedErr: "duplicate query 'UsersGetUser'",
+		},
+		{
+			name: "CollidingCases",
+			files: map[string]string{
+				"queries/users/get_user.sql": "select 1",
+				"queries/users/GetUser.sql":  "select 2",
+			},
+			expectedErr: "duplicate query 'UsersGetUser'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+			for path, contents := range tt.files {
+				require.NoError(t, afero.WriteFile(fs, path, []byte(contents), 0644))
+			}
+			require.NoError(t, fs.MkdirAll("out", 0755))
+
+			engine := &recordingEngine{}
+			o := otter.New(engine, emptyPrinter{},
+				otter.WithFS(fs),
+				otter.WithStderr(io.Discard),
+				otter.WithInitialisms(tt.initialisms),
+			)
+
+			err := o.Run(context.Background(), "queries", "out")
+			if tt.expectedErr != "" {
+				require.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+
+			queries := make(map[string]string, len(engine.queries))
+			for name, source := range engine.queries {
+				queries[name] = source.SQL
+			}
+			assert.Equal(t, tt.expected, queries)
+
+			for name, position := range tt.expectedPositions {
+				source := engine.queries[name]
+				assert.Equal(t, position, fmt.Sprintf("%s:%d", source.File, source.Line))
+			}
+		})
+	}
+}